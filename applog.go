@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+)
+
+// maxLogSize is the size at which the log file is rotated on startup;
+// one older file is kept as tasktracker.log.1.
+const maxLogSize = 1 << 20
+
+// logPath returns the location of the log file in the config directory.
+func logPath() string {
+	return filepath.Join(appConfigDir(), "tasktracker.log")
+}
+
+// setupLogging makes slog write JSON lines to the log file. If the file cannot
+// be opened the log goes to stderr instead. The returned closer closes the file.
+func setupLogging() io.Closer {
+	path := logPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err == nil {
+		if info, err := os.Stat(path); err == nil && info.Size() > maxLogSize {
+			os.Rename(path, path+".1")
+		}
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		slog.SetDefault(slog.New(slog.NewJSONHandler(os.Stderr, nil)))
+		slog.Warn("cannot open log file", "path", path, "err", err)
+		return io.NopCloser(nil)
+	}
+	slog.SetDefault(slog.New(slog.NewJSONHandler(f, nil)))
+	return f
+}
+
+// showError logs err and shows it in an error dialog on the main window.
+// what names the failed action like "saving session".
+func showError(what string, err error) {
+	slog.Error(what, "err", err)
+	windows := fyne.CurrentApp().Driver().AllWindows()
+	if len(windows) == 0 {
+		return
+	}
+	dialog.ShowError(fmt.Errorf("Error %s: %w", what, err), windows[0])
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// commands lists the command line subcommands; anything else starts the GUI.
+var commands = map[string]func(args []string, stdout io.Writer) error{
+	"export": exportCommand,
+}
+
+// unlockEnvVar holds the passphrase commands use to open an encrypted database.
+const unlockEnvVar = "TASKTRACKER_PASSPHRASE"
+
+// isCommand reports whether name is a command line subcommand.
+func isCommand(name string) bool {
+	_, ok := commands[name]
+	return ok
+}
+
+// runCommand runs a subcommand; args[0] is its name.
+func runCommand(args []string, stdout io.Writer) error {
+	return commands[args[0]](args[1:], stdout)
+}
+
+// commandStore returns the session store of a command. An encrypted database
+// is unlocked with the passphrase in unlockEnvVar.
+func commandStore(db *sql.DB) (SessionStore, error) {
+	fc := &fieldCipher{}
+	encrypted, err := isEncrypted(db)
+	if err != nil {
+		return nil, err
+	}
+	if encrypted {
+		passphrase, ok := os.LookupEnv(unlockEnvVar)
+		if !ok {
+			return nil, fmt.Errorf("the database is encrypted, set %s to its passphrase", unlockEnvVar)
+		}
+		key, err := unlockDatabase(db, passphrase)
+		if err != nil {
+			return nil, err
+		}
+		if err := fc.setKey(key); err != nil {
+			return nil, err
+		}
+	}
+	return &encryptedStore{inner: newSQLiteStore(db), cipher: fc}, nil
+}
+
+// exportCommand runs a saved export preset or a user template:
+//
+//	taskTracker export --preset NAME --out FILE [--from TIME] [--to TIME]
+//	taskTracker export --template FILE.tmpl --out FILE [--from TIME] [--to TIME]
+//	taskTracker export --list
+//
+// --out - writes to stdout. --from, --to, --mode and --project override the preset.
+// --workspace or --db export another database than the last used workspace.
+// Encrypted databases need their passphrase in TASKTRACKER_PASSPHRASE.
+func exportCommand(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	presetName := fs.String("preset", "", "name of the export preset to run")
+	templateFile := fs.String("template", "", "template in the templates folder to run")
+	out := fs.String("out", "", "output file, - for stdout")
+	from := fs.String("from", "", "range start, overrides the preset (e.g. monday, 2024-01-01)")
+	to := fs.String("to", "", "range end, overrides the preset")
+	project := fs.String("project", "", "only sessions of this project, overrides the preset")
+	mode := fs.String("mode", "", "range mode: contained, overlapping or clipped; overrides the preset")
+	list := fs.Bool("list", false, "list saved presets and templates")
+	workspace, dbPath := databaseFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig(filepath.Join(appConfigDir(), "config.json"))
+	if err != nil {
+		return err
+	}
+
+	if *list {
+		for _, p := range cfg.Export.Presets {
+			fmt.Fprintf(stdout, "preset\t%s\t%s\t%d columns\n", p.Name, p.Format, len(p.Columns))
+		}
+		names, err := userTemplates()
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			fmt.Fprintf(stdout, "template\t%s\n", name)
+		}
+		return nil
+	}
+
+	if (*presetName == "") == (*templateFile == "") || *out == "" {
+		return fmt.Errorf("usage: taskTracker export (--preset NAME | --template FILE.tmpl) --out FILE [--from TIME] [--to TIME]")
+	}
+	p := ExportPreset{Format: templateFormatPrefix + *templateFile}
+	if *presetName != "" {
+		var ok bool
+		p, ok = cfg.exportPreset(*presetName)
+		if !ok {
+			return fmt.Errorf("no export preset named %q (see taskTracker export --list)", *presetName)
+		}
+	}
+	if *from != "" {
+		p.From, p.To = *from, *to
+	} else if *to != "" {
+		p.To = *to
+	}
+	if *project != "" {
+		p.Project = *project
+	}
+	if *mode != "" {
+		p.RangeMode = *mode
+	}
+	if err := p.validate(); err != nil {
+		return err
+	}
+
+	_, path, err := cfg.resolveDatabase(*workspace, *dbPath)
+	if err != nil {
+		return err
+	}
+	db, err := openDatabase(path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	store, err := commandStore(db)
+	if err != nil {
+		return err
+	}
+
+	if *out == "-" {
+		_, err := runExport(store, cfg, p, stdout, time.Now())
+		return err
+	}
+
+	// a failed export leaves no partial file behind
+	f, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	exported, err := runExport(store, cfg, p, f, time.Now())
+	if err != nil {
+		f.Close()
+		os.Remove(*out)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(*out)
+		return err
+	}
+	fmt.Fprintf(stdout, "Exported %d sessions to %s\n", exported, *out)
+	return nil
+}
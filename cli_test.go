@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// useTestTemplates points the config directory at a fresh temporary one and
+// writes templates into its templates folder.
+func useTestTemplates(t *testing.T, templates map[string]string) {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", home)
+	t.Setenv("AppData", home)
+	if err := os.MkdirAll(templateDir(), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, text := range templates {
+		if err := os.WriteFile(filepath.Join(templateDir(), name), []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestExportCommandOutputFile(t *testing.T) {
+	useTestTemplates(t, map[string]string{
+		"count.txt.tmpl":  "{{len .Sessions}} sessions\n",
+		"broken.txt.tmpl": "first line\n{{index .Sessions 5}}",
+	})
+	dir := t.TempDir()
+	db := filepath.Join(dir, "sessions.db")
+	out := filepath.Join(dir, "export.txt")
+
+	var stdout bytes.Buffer
+	err := exportCommand([]string{"--db", db, "--template", "broken.txt.tmpl", "--out", out}, &stdout)
+	if err == nil {
+		t.Fatal("export with a failing template succeeded")
+	}
+	if _, err := os.Stat(out); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("failed export left %s behind (stat: %v)", out, err)
+	}
+
+	if err := exportCommand([]string{"--db", db, "--template", "count.txt.tmpl", "--out", out}, &stdout); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := os.ReadFile(out); err != nil || string(got) != "0 sessions\n" {
+		t.Errorf("export wrote %q (%v), want %q", got, err, "0 sessions\n")
+	}
+	if stdout.String() != "Exported 0 sessions to "+out+"\n" {
+		t.Errorf("stdout = %q", stdout.String())
+	}
+}
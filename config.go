@@ -0,0 +1,302 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Config holds user settings that are not stored in the database.
+// It is persisted as JSON in the TaskTracker config directory.
+type Config struct {
+	// Rounding is the global billing rounding policy; projects may override it.
+	Rounding RoundingPolicy `json:"rounding"`
+	// Currency is the default ISO 4217 currency for new sessions.
+	Currency string `json:"currency"`
+	// DBPath overrides the database location of the default workspace;
+	// empty means defaultDBPath().
+	DBPath string `json:"db_path"`
+	// Workspaces are the named databases besides the default one.
+	Workspaces []Workspace `json:"workspaces,omitempty"`
+	// Workspace is the last used workspace; empty means the default one.
+	Workspace string `json:"workspace,omitempty"`
+	// DefaultRate prefills the hourly rate inputs (0 leaves them empty).
+	DefaultRate Money `json:"default_rate_cents"`
+	// Locale overrides the system locale for money formatting, e.g. "de-CH".
+	Locale string `json:"locale"`
+	// WeekStart is the first day of a week for weekly reports ("monday", "sunday", ...).
+	WeekStart string `json:"week_start"`
+	// Export holds defaults for the export tab.
+	Export ExportDefaults `json:"export"`
+	// Theme is "system", "light" or "dark".
+	Theme string `json:"theme"`
+	// GitRepos are local repositories whose commits are shown with sessions.
+	GitRepos []string `json:"git_repos,omitempty"`
+	// GitAuthor selects the commits to show; empty means each repository's user.email.
+	GitAuthor string `json:"git_author,omitempty"`
+	// Webhooks receive session events.
+	Webhooks []Webhook `json:"webhooks,omitempty"`
+	// MetricsAddr is where /metrics is served for Prometheus, e.g.
+	// "127.0.0.1:9464"; empty turns the endpoint off.
+	MetricsAddr string `json:"metrics_addr,omitempty"`
+	// MetricsTitles labels the metrics with session titles. It is off by
+	// default because the endpoint has no authentication, and ignored for
+	// encrypted workspaces.
+	MetricsTitles bool `json:"metrics_titles,omitempty"`
+	// UserName and UserEmail identify the user on new sessions, for team reports.
+	UserName  string `json:"user_name,omitempty"`
+	UserEmail string `json:"user_email,omitempty"`
+	// TeamFolder is a folder shared with the team, e.g. by a sync tool, that
+	// holds the JSON export of every member.
+	TeamFolder string `json:"team_folder,omitempty"`
+	// WeeklyTargetMinutes is the contracted working time per week; 0 means none.
+	WeeklyTargetMinutes int `json:"weekly_target_minutes,omitempty"`
+	// WorkDays are the days sharing the weekly target ("monday", ...);
+	// empty means Monday to Friday.
+	WorkDays []string `json:"work_days,omitempty"`
+
+	path string
+}
+
+// ExportDefaults are the user's preferred export settings.
+type ExportDefaults struct {
+	CSVDelimiter string         `json:"csv_delimiter"`
+	Directory    string         `json:"directory"`
+	Presets      []ExportPreset `json:"presets,omitempty"`
+}
+
+// Workspace is a named database, e.g. to keep freelance and employer work apart.
+type Workspace struct {
+	Name string `json:"name"`
+	// DBPath is the database file; empty means <config>/workspaces/<name>.db.
+	DBPath string `json:"db_path,omitempty"`
+}
+
+// defaultWorkspace is the name of the workspace using DBPath.
+const defaultWorkspace = "default"
+
+// appConfigDir returns the TaskTracker directory inside the user config dir.
+func appConfigDir() string {
+	userConfigDir, err := os.UserConfigDir()
+	if err != nil {
+		userConfigDir = "."
+	}
+	return filepath.Join(userConfigDir, "TaskTracker")
+}
+
+// defaultConfig returns the settings used when no config file exists yet.
+func defaultConfig() *Config {
+	return &Config{
+		Rounding:  RoundingPolicy{Mode: roundingNone},
+		Currency:  defaultCurrency,
+		WeekStart: "monday",
+		Export:    ExportDefaults{CSVDelimiter: ";"},
+		Theme:     themeSystem,
+	}
+}
+
+// defaultDBPath is the database location used when none is configured.
+func defaultDBPath() string {
+	return filepath.Join(appConfigDir(), "taskTracker.db")
+}
+
+// workspaceNames lists the default workspace followed by the named ones.
+func (c *Config) workspaceNames() []string {
+	names := []string{defaultWorkspace}
+	for _, w := range c.Workspaces {
+		names = append(names, w.Name)
+	}
+	return names
+}
+
+// workspacePath returns the database file of a workspace; "" is the default one.
+func (c *Config) workspacePath(name string) (string, bool) {
+	if name == "" || name == defaultWorkspace {
+		if c.DBPath != "" {
+			return c.DBPath, true
+		}
+		return defaultDBPath(), true
+	}
+	for _, w := range c.Workspaces {
+		if w.Name == name {
+			if w.DBPath != "" {
+				return w.DBPath, true
+			}
+			return workspaceFile(name), true
+		}
+	}
+	return "", false
+}
+
+// workspaceFile is the database of a named workspace without its own path.
+func workspaceFile(name string) string {
+	return filepath.Join(appConfigDir(), "workspaces", name+".db")
+}
+
+// checkWorkspaceName rejects names that are taken or unusable as file names.
+func (c *Config) checkWorkspaceName(name string) error {
+	if name == "" || strings.ContainsAny(name, `/\:`) || name == "." || name == ".." {
+		return fmt.Errorf("invalid workspace name %q", name)
+	}
+	if _, exists := c.workspacePath(name); exists {
+		return fmt.Errorf("workspace %q already exists", name)
+	}
+	return nil
+}
+
+// addWorkspace adds a named workspace.
+func (c *Config) addWorkspace(w Workspace) error {
+	w.Name = strings.TrimSpace(w.Name)
+	w.DBPath = strings.TrimSpace(w.DBPath)
+	if err := c.checkWorkspaceName(w.Name); err != nil {
+		return err
+	}
+	c.Workspaces = append(c.Workspaces, w)
+	return nil
+}
+
+// deleteWorkspace forgets a named workspace; its database file is kept.
+func (c *Config) deleteWorkspace(name string) {
+	c.Workspaces = slices.DeleteFunc(c.Workspaces, func(w Workspace) bool { return w.Name == name })
+	if c.Workspace == name {
+		c.Workspace = ""
+	}
+}
+
+// resolveDatabase picks the database from the --workspace and --db flags.
+// Without flags the last used workspace is opened. name is "" for --db.
+func (c *Config) resolveDatabase(workspace, db string) (name, path string, err error) {
+	switch {
+	case workspace != "" && db != "":
+		return "", "", errors.New("use either --workspace or --db, not both")
+	case db != "":
+		return "", db, nil
+	case workspace != "":
+		path, ok := c.workspacePath(workspace)
+		if !ok {
+			return "", "", fmt.Errorf("no workspace named %q", workspace)
+		}
+		return workspace, path, nil
+	}
+	if path, ok := c.workspacePath(c.Workspace); ok && c.Workspace != "" {
+		return c.Workspace, path, nil
+	}
+	path, _ = c.workspacePath(defaultWorkspace)
+	return defaultWorkspace, path, nil
+}
+
+// csvDelimiter returns the configured CSV delimiter, defaulting to ';'.
+func (c *Config) csvDelimiter() rune {
+	r := []rune(c.Export.CSVDelimiter)
+	if len(r) != 1 {
+		return ';'
+	}
+	return r[0]
+}
+
+// weekStartDay returns the configured first day of the week, defaulting to Monday.
+func (c *Config) weekStartDay() time.Weekday {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if strings.EqualFold(d.String(), c.WeekStart) {
+			return d
+		}
+	}
+	return time.Monday
+}
+
+// exportPreset returns the preset with the given name.
+func (c *Config) exportPreset(name string) (ExportPreset, bool) {
+	for _, p := range c.Export.Presets {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return ExportPreset{}, false
+}
+
+// exportPresetNames lists the saved presets.
+func (c *Config) exportPresetNames() []string {
+	names := make([]string, len(c.Export.Presets))
+	for i, p := range c.Export.Presets {
+		names[i] = p.Name
+	}
+	return names
+}
+
+// setExportPreset adds p or replaces the preset with the same name.
+func (c *Config) setExportPreset(p ExportPreset) {
+	for i := range c.Export.Presets {
+		if c.Export.Presets[i].Name == p.Name {
+			c.Export.Presets[i] = p
+			return
+		}
+	}
+	c.Export.Presets = append(c.Export.Presets, p)
+}
+
+// deleteExportPreset removes the preset with the given name.
+func (c *Config) deleteExportPreset(name string) {
+	c.Export.Presets = slices.DeleteFunc(c.Export.Presets, func(p ExportPreset) bool { return p.Name == name })
+}
+
+// defaultRateText returns the default hourly rate for prefilling inputs, or "".
+func (c *Config) defaultRateText() string {
+	if c.DefaultRate == 0 {
+		return ""
+	}
+	return c.DefaultRate.Decimal()
+}
+
+// loadConfig reads the config file at path. A missing file yields the defaults.
+func loadConfig(path string) (*Config, error) {
+	cfg := defaultConfig()
+	cfg.path = path
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, err
+	}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return cfg, err
+	}
+	return cfg, nil
+}
+
+// save writes the config back to the file it was loaded from. The file holds
+// webhook secrets, so only the user may read it; it is written to a temporary
+// file first so that a failed save never leaves half a config behind.
+func (c *Config) save() error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(c.path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, ".config-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), c.path)
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestConfigSave(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "TaskTracker", "config.json")
+	// an older version wrote the file readable for everyone
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(`{"Currency": "USD"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := loadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Webhooks = []Webhook{{URL: "https://example.com/hook", Secret: "s3cret"}}
+	if err := cfg.save(); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Windows has no permission bits for others
+	if runtime.GOOS != "windows" && info.Mode().Perm() != 0600 {
+		t.Errorf("config saved with mode %v, want 0600", info.Mode().Perm())
+	}
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("save left temporary files behind: %v", entries)
+	}
+
+	loaded, err := loadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Currency != "USD" || len(loaded.Webhooks) != 1 || loaded.Webhooks[0].Secret != "s3cret" {
+		t.Errorf("loaded config = %+v", loaded)
+	}
+}
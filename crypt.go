@@ -0,0 +1,543 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/argon2"
+)
+
+// Encryption at rest protects the session title and description, which may
+// contain client details. Each encrypted database has a random data key; the
+// key is stored wrapped (AES-GCM) with a key derived from the passphrase by
+// Argon2id, so changing the passphrase only re-wraps the data key. Times,
+// amounts and project names stay readable so the database can be queried.
+
+// errWrongPassphrase is returned when a passphrase does not unwrap the data key.
+var errWrongPassphrase = errors.New("wrong passphrase")
+
+// encryptedPrefix marks an encrypted column value; the rest is base64 of nonce and ciphertext.
+const encryptedPrefix = "enc:v1:"
+
+// minPassphraseLength is the shortest passphrase accepted for new keys.
+const minPassphraseLength = 8
+
+// kdfParams are the Argon2id parameters, stored with each wrapped key so
+// they can be raised later without breaking existing databases and backups.
+type kdfParams struct {
+	Time    uint32
+	Memory  uint32 // KiB
+	Threads uint8
+}
+
+// sane reports whether parameters read from a file can be used; it keeps a
+// crafted backup from asking for gigabytes of memory.
+func (p kdfParams) sane() bool {
+	return p.Time >= 1 && p.Time <= 16 && p.Memory >= 8*1024 && p.Memory <= 1024*1024 && p.Threads >= 1
+}
+
+// defaultKDF follows the Argon2id recommendation of RFC 9106 for
+// memory-constrained environments.
+var defaultKDF = kdfParams{Time: 3, Memory: 64 * 1024, Threads: 4}
+
+// deriveKey turns a passphrase into a 256-bit key.
+func deriveKey(passphrase string, salt []byte, p kdfParams) []byte {
+	return argon2.IDKey([]byte(passphrase), salt, p.Time, p.Memory, p.Threads, 32)
+}
+
+// randomBytes returns n bytes from the system's secure random source.
+func randomBytes(n int) ([]byte, error) {
+	b := make([]byte, n)
+	_, err := io.ReadFull(rand.Reader, b)
+	return b, err
+}
+
+// newGCM returns AES-256-GCM for key.
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts plain with a random nonce and returns nonce and ciphertext.
+func seal(aead cipher.AEAD, plain, additional []byte) ([]byte, error) {
+	nonce, err := randomBytes(aead.NonceSize())
+	if err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plain, additional), nil
+}
+
+// unseal reverses seal.
+func unseal(aead cipher.AEAD, sealed, additional []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("encrypted value too short")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, additional)
+}
+
+// createEncryptionTable creates the table holding the wrapped data key.
+// It has no row while the database is not encrypted.
+func createEncryptionTable(db *sql.DB) error {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS encryption (
+		id INTEGER PRIMARY KEY CHECK (id = 1),
+		salt BLOB NOT NULL,
+		kdf_time INTEGER NOT NULL,
+		kdf_memory INTEGER NOT NULL,
+		kdf_threads INTEGER NOT NULL,
+		wrapped_key BLOB NOT NULL
+	)`)
+	return err
+}
+
+// keySlot is the wrapped data key of an encrypted database.
+type keySlot struct {
+	salt       []byte
+	kdf        kdfParams
+	wrappedKey []byte
+}
+
+// loadKeySlot returns the database's key slot, or nil if it is not encrypted.
+func loadKeySlot(db *sql.DB) (*keySlot, error) {
+	var k keySlot
+	err := db.QueryRow("SELECT salt, kdf_time, kdf_memory, kdf_threads, wrapped_key FROM encryption WHERE id = 1").
+		Scan(&k.salt, &k.kdf.Time, &k.kdf.Memory, &k.kdf.Threads, &k.wrappedKey)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &k, nil
+}
+
+// isEncrypted reports whether the database has a data key.
+func isEncrypted(db *sql.DB) (bool, error) {
+	k, err := loadKeySlot(db)
+	return k != nil, err
+}
+
+// wrapKey wraps the data key with a new salt derived key of passphrase.
+func wrapKey(dataKey []byte, passphrase string) (*keySlot, error) {
+	salt, err := randomBytes(16)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newGCM(deriveKey(passphrase, salt, defaultKDF))
+	if err != nil {
+		return nil, err
+	}
+	wrapped, err := seal(aead, dataKey, []byte("taskTracker data key"))
+	if err != nil {
+		return nil, err
+	}
+	return &keySlot{salt: salt, kdf: defaultKDF, wrappedKey: wrapped}, nil
+}
+
+// unwrap returns the data key, or errWrongPassphrase.
+func (k *keySlot) unwrap(passphrase string) ([]byte, error) {
+	aead, err := newGCM(deriveKey(passphrase, k.salt, k.kdf))
+	if err != nil {
+		return nil, err
+	}
+	key, err := unseal(aead, k.wrappedKey, []byte("taskTracker data key"))
+	if err != nil {
+		return nil, errWrongPassphrase
+	}
+	return key, nil
+}
+
+// storeKeySlot writes the key slot, replacing an existing one.
+func storeKeySlot(tx *sql.Tx, k *keySlot) error {
+	_, err := tx.Exec("INSERT OR REPLACE INTO encryption (id, salt, kdf_time, kdf_memory, kdf_threads, wrapped_key) VALUES (1, ?, ?, ?, ?, ?)",
+		k.salt, k.kdf.Time, k.kdf.Memory, k.kdf.Threads, k.wrappedKey)
+	return err
+}
+
+// unlockDatabase returns the data key of an encrypted database.
+func unlockDatabase(db *sql.DB, passphrase string) ([]byte, error) {
+	k, err := loadKeySlot(db)
+	if err != nil {
+		return nil, err
+	}
+	if k == nil {
+		return nil, errors.New("database is not encrypted")
+	}
+	return k.unwrap(passphrase)
+}
+
+// enableEncryption creates a data key protected by passphrase and encrypts
+// the title and description of all stored sessions in one transaction.
+func enableEncryption(db *sql.DB, passphrase string) ([]byte, error) {
+	if len(passphrase) < minPassphraseLength {
+		return nil, fmt.Errorf("the passphrase needs at least %d characters", minPassphraseLength)
+	}
+	if encrypted, err := isEncrypted(db); err != nil || encrypted {
+		if err == nil {
+			err = errors.New("database is already encrypted")
+		}
+		return nil, err
+	}
+	dataKey, err := randomBytes(32)
+	if err != nil {
+		return nil, err
+	}
+	slot, err := wrapKey(dataKey, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	fc := &fieldCipher{}
+	if err := fc.setKey(dataKey); err != nil {
+		return nil, err
+	}
+
+	// both session tables are keyed by uuid, which is part of the authenticated data
+	type row struct {
+		table, uuid, title, description string
+	}
+	var plain []row
+	for _, table := range []string{"work_sessions", "team_sessions"} {
+		rows, err := db.Query("SELECT uuid, title, COALESCE(description, '') FROM " + table)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			r := row{table: table}
+			if err := rows.Scan(&r.uuid, &r.title, &r.description); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			plain = append(plain, r)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range plain {
+		title, err := fc.seal("title", r.uuid, r.title)
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		description, err := fc.seal("description", r.uuid, r.description)
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		if _, err := tx.Exec("UPDATE "+r.table+" SET title = ?, description = ? WHERE uuid = ?", title, description, r.uuid); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+	if err := storeKeySlot(tx, slot); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	// the old plaintext may survive in free pages until the file is rewritten
+	if _, err := db.Exec("VACUUM"); err != nil {
+		return dataKey, fmt.Errorf("sessions encrypted, but removing the unencrypted copies failed: %w", err)
+	}
+	return dataKey, nil
+}
+
+// changePassphrase re-wraps the data key; the sessions stay as they are.
+func changePassphrase(db *sql.DB, oldPassphrase, newPassphrase string) error {
+	if len(newPassphrase) < minPassphraseLength {
+		return fmt.Errorf("the passphrase needs at least %d characters", minPassphraseLength)
+	}
+	dataKey, err := unlockDatabase(db, oldPassphrase)
+	if err != nil {
+		return err
+	}
+	slot, err := wrapKey(dataKey, newPassphrase)
+	if err != nil {
+		return err
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	if err := storeKeySlot(tx, slot); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// fieldCipher encrypts session fields with the data key. Without a key it
+// passes values through, so unencrypted databases work as before.
+type fieldCipher struct {
+	mu   sync.RWMutex
+	aead cipher.AEAD
+}
+
+// setKey starts encrypting with the data key.
+func (c *fieldCipher) setKey(key []byte) error {
+	aead, err := newGCM(key)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.aead = aead
+	c.mu.Unlock()
+	return nil
+}
+
+// enabled reports whether new values are encrypted.
+func (c *fieldCipher) enabled() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.aead != nil
+}
+
+// seal encrypts the value of a field of the session with the uuid. The field
+// name and uuid are authenticated, so values cannot be swapped between them.
+func (c *fieldCipher) seal(field, sessionUUID, value string) (string, error) {
+	c.mu.RLock()
+	aead := c.aead
+	c.mu.RUnlock()
+	if aead == nil {
+		return value, nil
+	}
+	sealed, err := seal(aead, []byte(value), []byte(field+"\x00"+sessionUUID))
+	if err != nil {
+		return "", err
+	}
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// open decrypts a value written by seal; unencrypted values are returned as they are.
+func (c *fieldCipher) open(field, sessionUUID, value string) (string, error) {
+	data, ok := strings.CutPrefix(value, encryptedPrefix)
+	if !ok {
+		return value, nil
+	}
+	c.mu.RLock()
+	aead := c.aead
+	c.mu.RUnlock()
+	if aead == nil {
+		return "", errors.New("the database is locked")
+	}
+	sealed, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return "", err
+	}
+	plain, err := unseal(aead, sealed, []byte(field+"\x00"+sessionUUID))
+	if err != nil {
+		return "", fmt.Errorf("cannot decrypt %s of session %s", field, sessionUUID)
+	}
+	return string(plain), nil
+}
+
+// encryptedStore encrypts title and description on their way into the inner
+// store and decrypts them on their way out.
+type encryptedStore struct {
+	inner  SessionStore
+	cipher *fieldCipher
+}
+
+// sealSession returns s with encrypted fields. A new session gets its uuid
+// here because the uuid is part of the authenticated data.
+func (st *encryptedStore) sealSession(s Session) (Session, error) {
+	if s.uuid == "" {
+		s.uuid = uuid.New().String()
+	}
+	var err error
+	if s.Title, err = st.cipher.seal("title", s.uuid, s.Title); err != nil {
+		return s, err
+	}
+	s.Description, err = st.cipher.seal("description", s.uuid, s.Description)
+	return s, err
+}
+
+func (st *encryptedStore) openSession(s Session) (Session, error) {
+	var err error
+	if s.Title, err = st.cipher.open("title", s.uuid, s.Title); err != nil {
+		return s, err
+	}
+	s.Description, err = st.cipher.open("description", s.uuid, s.Description)
+	return s, err
+}
+
+func (st *encryptedStore) Create(s *Session) error {
+	sealed, err := st.sealSession(*s)
+	if err != nil {
+		return err
+	}
+	if err := st.inner.Create(&sealed); err != nil {
+		return err
+	}
+	sealed.Title, sealed.Description = s.Title, s.Description
+	*s = sealed
+	return nil
+}
+
+func (st *encryptedStore) Get(id int) (Session, error) {
+	s, err := st.inner.Get(id)
+	if err != nil {
+		return s, err
+	}
+	return st.openSession(s)
+}
+
+func (st *encryptedStore) Update(s Session) error {
+	sealed, err := st.sealSession(s)
+	if err != nil {
+		return err
+	}
+	return st.inner.Update(sealed)
+}
+
+func (st *encryptedStore) Delete(id int) error {
+	return st.inner.Delete(id)
+}
+
+func (st *encryptedStore) List(filter SessionFilter) ([]Session, error) {
+	sessions, err := st.inner.List(filter)
+	if err != nil {
+		return nil, err
+	}
+	for i := range sessions {
+		if sessions[i], err = st.openSession(sessions[i]); err != nil {
+			return nil, err
+		}
+	}
+	return sessions, nil
+}
+
+// RecentTasks groups in memory when encrypted, because equal titles have
+// different ciphertexts.
+func (st *encryptedStore) RecentTasks(limit int) ([]RecentTask, error) {
+	if !st.cipher.enabled() {
+		return st.inner.RecentTasks(limit)
+	}
+	sessions, err := st.List(SessionFilter{})
+	if err != nil {
+		return nil, err
+	}
+	return recentTasks(sessions, limit), nil
+}
+
+// Encrypted backups are a copy of the whole database file encrypted with a
+// passphrase chosen for the backup:
+//
+//	magic | kdf time (4) | kdf memory (4) | kdf threads (1) | salt (16) | nonce | ciphertext
+const backupMagic = "TTBACKUP1\n"
+
+// backupExtension is the file extension of encrypted backups.
+const backupExtension = "ttbackup"
+
+// writeEncryptedBackup copies the database with VACUUM INTO and writes the
+// copy encrypted to w. The unencrypted copy is removed right away.
+func writeEncryptedBackup(db *sql.DB, w io.Writer, passphrase string) error {
+	if len(passphrase) < minPassphraseLength {
+		return fmt.Errorf("the passphrase needs at least %d characters", minPassphraseLength)
+	}
+	dir, err := os.MkdirTemp("", "tasktracker-backup")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	copyPath := filepath.Join(dir, "backup.db")
+	if _, err := db.Exec("VACUUM INTO ?", copyPath); err != nil {
+		return err
+	}
+	plain, err := os.ReadFile(copyPath)
+	if err != nil {
+		return err
+	}
+	os.Remove(copyPath)
+
+	salt, err := randomBytes(16)
+	if err != nil {
+		return err
+	}
+	aead, err := newGCM(deriveKey(passphrase, salt, defaultKDF))
+	if err != nil {
+		return err
+	}
+	header := []byte(backupMagic)
+	header = binary.BigEndian.AppendUint32(header, defaultKDF.Time)
+	header = binary.BigEndian.AppendUint32(header, defaultKDF.Memory)
+	header = append(header, defaultKDF.Threads)
+	header = append(header, salt...)
+	sealed, err := seal(aead, plain, header)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	_, err = w.Write(sealed)
+	return err
+}
+
+// restoreEncryptedBackup decrypts a backup into a new database file at path.
+// An existing file is never overwritten.
+func restoreEncryptedBackup(r io.Reader, passphrase, path string) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	headerLen := len(backupMagic) + 4 + 4 + 1 + 16
+	if len(data) < headerLen || string(data[:len(backupMagic)]) != backupMagic {
+		return errors.New("not a TaskTracker backup")
+	}
+	header := data[:headerLen]
+	fields := header[len(backupMagic):]
+	p := kdfParams{
+		Time:    binary.BigEndian.Uint32(fields[0:4]),
+		Memory:  binary.BigEndian.Uint32(fields[4:8]),
+		Threads: fields[8],
+	}
+	salt := fields[9:25]
+	if !p.sane() {
+		return errors.New("backup has invalid key derivation parameters")
+	}
+	aead, err := newGCM(deriveKey(passphrase, salt, p))
+	if err != nil {
+		return err
+	}
+	plain, err := unseal(aead, data[headerLen:], header)
+	if err != nil {
+		return errWrongPassphrase
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(plain); err != nil {
+		f.Close()
+		os.Remove(path)
+		return err
+	}
+	return f.Close()
+}
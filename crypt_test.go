@@ -0,0 +1,247 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testPassphrase = "correct horse"
+
+// unlockedStore returns a store that reads db with the key of passphrase.
+func unlockedStore(t *testing.T, db *sql.DB, passphrase string) SessionStore {
+	t.Helper()
+	key, err := unlockDatabase(db, passphrase)
+	if err != nil {
+		t.Fatalf("unlocking with %q: %v", passphrase, err)
+	}
+	fc := &fieldCipher{}
+	if err := fc.setKey(key); err != nil {
+		t.Fatal(err)
+	}
+	return &encryptedStore{inner: newSQLiteStore(db), cipher: fc}
+}
+
+// storedTitles returns the titles as they are in the database, by uuid.
+func storedTitles(t *testing.T, db *sql.DB) map[string]string {
+	t.Helper()
+	rows, err := db.Query("SELECT uuid, title FROM work_sessions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	titles := map[string]string{}
+	for rows.Next() {
+		var id, title string
+		if err := rows.Scan(&id, &title); err != nil {
+			t.Fatal(err)
+		}
+		titles[id] = title
+	}
+	return titles
+}
+
+func TestFieldCipher(t *testing.T) {
+	const id = "00000000-0000-4000-8000-000000000001"
+	plain := &fieldCipher{}
+	if got, err := plain.seal("title", id, "Write docs"); err != nil || got != "Write docs" {
+		t.Errorf("seal without key = %q, %v; want the value as it is", got, err)
+	}
+
+	fc := &fieldCipher{}
+	if err := fc.setKey(bytes.Repeat([]byte{1}, 32)); err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := fc.seal("title", id, "Write docs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(sealed, encryptedPrefix) || strings.Contains(sealed, "Write docs") {
+		t.Fatalf("sealed value %q is not encrypted", sealed)
+	}
+	if again, _ := fc.seal("title", id, "Write docs"); again == sealed {
+		t.Error("sealing twice gave the same ciphertext")
+	}
+	if got, err := fc.open("title", id, sealed); err != nil || got != "Write docs" {
+		t.Errorf("open = %q, %v; want %q", got, err, "Write docs")
+	}
+	if got, err := fc.open("title", id, "not encrypted"); err != nil || got != "not encrypted" {
+		t.Errorf("open of a plain value = %q, %v", got, err)
+	}
+
+	other := &fieldCipher{}
+	if err := other.setKey(bytes.Repeat([]byte{2}, 32)); err != nil {
+		t.Fatal(err)
+	}
+	failures := []struct {
+		name      string
+		fc        *fieldCipher
+		field, id string
+		value     string
+	}{
+		{"other session", fc, "title", "00000000-0000-4000-8000-000000000002", sealed},
+		{"other field", fc, "description", id, sealed},
+		{"other key", other, "title", id, sealed},
+		{"locked", plain, "title", id, sealed},
+		{"corrupted", fc, "title", id, sealed[:len(sealed)-4] + "AAA="},
+		{"not base64", fc, "title", id, encryptedPrefix + "%%%"},
+	}
+	for _, tt := range failures {
+		if got, err := tt.fc.open(tt.field, tt.id, tt.value); err == nil {
+			t.Errorf("%s: open = %q, want an error", tt.name, got)
+		}
+	}
+}
+
+func TestEnableEncryption(t *testing.T) {
+	db := openTestDB(t)
+	plainStore := newSQLiteStore(db)
+	docs := createTestSession(t, plainStore, "Write docs", "Website", testTime(9, 0), testTime(10, 0))
+	review := createTestSession(t, plainStore, "Review", "Website", testTime(10, 0), testTime(11, 0))
+
+	if _, err := enableEncryption(db, "short"); err == nil {
+		t.Error("enableEncryption accepted a short passphrase")
+	}
+	if _, err := enableEncryption(db, testPassphrase); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := enableEncryption(db, testPassphrase); err == nil {
+		t.Error("enableEncryption encrypted twice")
+	}
+	for id, title := range storedTitles(t, db) {
+		if !strings.HasPrefix(title, encryptedPrefix) {
+			t.Errorf("session %s has the plain title %q", id, title)
+		}
+	}
+
+	if _, err := unlockDatabase(db, "wrong passphrase"); !errors.Is(err, errWrongPassphrase) {
+		t.Errorf("unlock with the wrong passphrase: err = %v, want errWrongPassphrase", err)
+	}
+	store := unlockedStore(t, db, testPassphrase)
+	got, err := store.Get(docs.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Title != "Write docs" || got.Project != "Website" || got.uuid != docs.uuid {
+		t.Errorf("Get after enabling = %+v", got)
+	}
+
+	// new sessions are stored encrypted and read back through the store
+	added := createTestSession(t, store, "Secret client", "", testTime(12, 0), testTime(13, 0))
+	if title := storedTitles(t, db)[added.uuid]; !strings.HasPrefix(title, encryptedPrefix) {
+		t.Errorf("new session stored with title %q", title)
+	}
+	sessions, err := store.List(SessionFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var titles []string
+	for _, s := range sessions {
+		titles = append(titles, s.Title)
+	}
+	if strings.Join(titles, ",") != "Secret client,Review,Write docs" {
+		t.Errorf("List titles = %v", titles)
+	}
+	tasks, err := store.RecentTasks(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tasks) != 3 || tasks[0].Title != "Secret client" {
+		t.Errorf("RecentTasks = %+v", tasks)
+	}
+
+	// a ciphertext copied to another session or field does not open
+	if _, err := db.Exec("UPDATE work_sessions SET title = (SELECT title FROM work_sessions WHERE uuid = ?) WHERE uuid = ?", docs.uuid, review.uuid); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Get(review.ID); err == nil {
+		t.Error("title copied from another session was opened")
+	}
+	if _, err := db.Exec("UPDATE work_sessions SET title = description WHERE uuid = ?", docs.uuid); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Get(docs.ID); err == nil {
+		t.Error("description copied into the title was opened")
+	}
+}
+
+func TestChangePassphrase(t *testing.T) {
+	db := openTestDB(t)
+	if _, err := enableEncryption(db, testPassphrase); err != nil {
+		t.Fatal(err)
+	}
+	s := createTestSession(t, unlockedStore(t, db, testPassphrase), "Write docs", "", testTime(9, 0), testTime(10, 0))
+
+	const newPassphrase = "battery staple"
+	if err := changePassphrase(db, "wrong passphrase", newPassphrase); !errors.Is(err, errWrongPassphrase) {
+		t.Errorf("change with the wrong passphrase: err = %v, want errWrongPassphrase", err)
+	}
+	if err := changePassphrase(db, testPassphrase, "short"); err == nil {
+		t.Error("changePassphrase accepted a short passphrase")
+	}
+	if err := changePassphrase(db, testPassphrase, newPassphrase); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := unlockDatabase(db, testPassphrase); !errors.Is(err, errWrongPassphrase) {
+		t.Errorf("old passphrase still unlocks: err = %v", err)
+	}
+	got, err := unlockedStore(t, db, newPassphrase).Get(s.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Title != "Write docs" {
+		t.Errorf("title after changing the passphrase = %q", got.Title)
+	}
+}
+
+func TestEncryptedBackup(t *testing.T) {
+	db := openTestDB(t)
+	if _, err := enableEncryption(db, testPassphrase); err != nil {
+		t.Fatal(err)
+	}
+	s := createTestSession(t, unlockedStore(t, db, testPassphrase), "Write docs", "Website", testTime(9, 0), testTime(10, 0))
+
+	const backupPassphrase = "backup passphrase"
+	if err := writeEncryptedBackup(db, &bytes.Buffer{}, "short"); err == nil {
+		t.Error("writeEncryptedBackup accepted a short passphrase")
+	}
+	var backup bytes.Buffer
+	if err := writeEncryptedBackup(db, &backup, backupPassphrase); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(backup.Bytes(), []byte(backupMagic)) || bytes.Contains(backup.Bytes(), []byte("Website")) {
+		t.Fatal("backup is not encrypted")
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "restored.db")
+	if err := restoreEncryptedBackup(bytes.NewReader(backup.Bytes()), testPassphrase, path); !errors.Is(err, errWrongPassphrase) {
+		t.Errorf("restore with the wrong passphrase: err = %v, want errWrongPassphrase", err)
+	}
+	if err := restoreEncryptedBackup(strings.NewReader("not a backup"), backupPassphrase, path); err == nil {
+		t.Error("restored something that is not a backup")
+	}
+	if err := restoreEncryptedBackup(bytes.NewReader(backup.Bytes()), backupPassphrase, path); err != nil {
+		t.Fatal(err)
+	}
+	if err := restoreEncryptedBackup(bytes.NewReader(backup.Bytes()), backupPassphrase, path); err == nil {
+		t.Error("restore overwrote an existing file")
+	}
+
+	restored, err := openDatabase(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer restored.Close()
+	// the restored database is still protected by its own passphrase
+	got, err := unlockedStore(t, restored, testPassphrase).Get(s.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Title != "Write docs" || got.Project != "Website" || got.uuid != s.uuid {
+		t.Errorf("restored session = %+v", got)
+	}
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"sort"
+	"strings"
+
+	"fyne.io/fyne/v2/lang"
+)
+
+// defaultCurrency is used for sessions recorded before currencies were introduced.
+const defaultCurrency = "EUR"
+
+// currencySymbols maps ISO 4217 codes to the symbol shown in the UI.
+var currencySymbols = map[string]string{
+	"EUR": "€",
+	"USD": "$",
+	"CHF": "CHF",
+	"GBP": "£",
+	"CAD": "CA$",
+	"AUD": "A$",
+	"SEK": "kr",
+	"NOK": "kr",
+	"DKK": "kr",
+	"PLN": "zł",
+}
+
+// currencyCodes lists the supported currencies for select widgets.
+var currencyCodes = []string{"EUR", "USD", "CHF", "GBP", "CAD", "AUD", "SEK", "NOK", "DKK", "PLN"}
+
+// numberFormat describes how a locale writes money amounts.
+type numberFormat struct {
+	decimal     string
+	group       string
+	symbolFirst bool
+	space       bool // space between symbol and amount
+}
+
+// localeFormats holds number formats by language (and a few language-region overrides).
+var localeFormats = map[string]numberFormat{
+	"en":    {decimal: ".", group: ",", symbolFirst: true},
+	"de":    {decimal: ",", group: ".", space: true},
+	"de-CH": {decimal: ".", group: "'", symbolFirst: true, space: true},
+	"fr":    {decimal: ",", group: " ", space: true},
+	"fr-CH": {decimal: ",", group: " ", space: true},
+	"it":    {decimal: ",", group: ".", space: true},
+	"es":    {decimal: ",", group: ".", space: true},
+	"nl":    {decimal: ",", group: ".", symbolFirst: true, space: true},
+	"sv":    {decimal: ",", group: " ", space: true},
+	"pl":    {decimal: ",", group: " ", space: true},
+}
+
+// systemLocale is the locale detected from the operating system.
+var systemLocale = lang.SystemLocale().LanguageString()
+
+// moneyLocale is the locale used for formatting: the configured locale or the system one.
+var moneyLocale = systemLocale
+
+// formatFor returns the number format for a locale like "de-CH", falling back
+// to the language and finally to English.
+func formatFor(locale string) numberFormat {
+	if f, ok := localeFormats[locale]; ok {
+		return f
+	}
+	language, _, _ := strings.Cut(locale, "-")
+	if f, ok := localeFormats[language]; ok {
+		return f
+	}
+	return localeFormats["en"]
+}
+
+// currencySymbol returns the display symbol for code (the code itself if unknown).
+func currencySymbol(code string) string {
+	if code == "" {
+		code = defaultCurrency
+	}
+	if sym, ok := currencySymbols[code]; ok {
+		return sym
+	}
+	return code
+}
+
+// formatMoney formats amount in currency code using the current locale,
+// e.g. "1.234,50 €" for de or "$1,234.50" for en.
+func formatMoney(amount Money, code string) string {
+	f := formatFor(moneyLocale)
+
+	negative := amount < 0
+	if negative {
+		amount = -amount
+	}
+	intPart, fracPart, _ := strings.Cut(amount.Decimal(), ".")
+
+	// insert group separators from the right
+	var grouped strings.Builder
+	for i, r := range intPart {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			grouped.WriteString(f.group)
+		}
+		grouped.WriteRune(r)
+	}
+	number := grouped.String() + f.decimal + fracPart
+
+	sym := currencySymbol(code)
+	sep := ""
+	if f.space || len(sym) > 1 && !strings.ContainsAny(sym, "$£€") {
+		sep = " "
+	}
+	var s string
+	if f.symbolFirst {
+		s = sym + sep + number
+	} else {
+		s = number + sep + sym
+	}
+	if negative {
+		s = "-" + s
+	}
+	return s
+}
+
+// formatRate formats an hourly rate like "45,00 €/h".
+func formatRate(rate Money, code string) string {
+	return formatMoney(rate, code) + "/h"
+}
+
+// formatTotals formats per-currency totals in a stable order, e.g. "120,00 € | $45.00".
+// Amounts in different currencies are never added together.
+func formatTotals(totals map[string]Money) string {
+	if len(totals) == 0 {
+		return formatMoney(0, defaultCurrency)
+	}
+	codes := make([]string, 0, len(totals))
+	for code := range totals {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	parts := make([]string, 0, len(codes))
+	for _, code := range codes {
+		parts = append(parts, formatMoney(totals[code], code))
+	}
+	return strings.Join(parts, " | ")
+}
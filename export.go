@@ -0,0 +1,268 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// export file formats
+const (
+	exportCSV  = "csv"
+	exportXLSX = "xlsx"
+	exportICS  = "ics"
+	exportJSON = "json"
+)
+
+// duration formats for text exports
+const (
+	durationClock   = "h:mm:ss"
+	durationHours   = "decimal hours"
+	durationMinutes = "minutes"
+	durationGo      = "1h2m3s"
+)
+
+var durationFormats = []string{durationClock, durationHours, durationMinutes, durationGo}
+
+// dateLayouts are offered in the export tab; any Go time layout is accepted.
+var dateLayouts = []string{displayLayout, "2006-01-02 15:04:05", time.RFC3339, "02.01.2006 15:04", "01/02/2006 3:04 PM"}
+
+// column value kinds, deciding how a value is formatted
+const (
+	kindText = iota
+	kindInt
+	kindTime
+	kindDuration
+	kindMoney
+)
+
+// exportColumn is one selectable column of a session export.
+type exportColumn struct {
+	Key    string
+	Header string
+	kind   int
+	total  bool // summed in the XLSX totals row
+	value  func(Session) any
+}
+
+// exportColumns lists all exportable columns in their export order.
+var exportColumns = []exportColumn{
+	{"id", "ID", kindInt, false, func(s Session) any { return int64(s.ID) }},
+	{"uuid", "UUID", kindText, false, func(s Session) any { return s.uuid }},
+	{"title", "Title", kindText, false, func(s Session) any { return s.Title }},
+	{"description", "Description", kindText, false, func(s Session) any { return s.Description }},
+	{"project", "Project", kindText, false, func(s Session) any { return s.Project }},
+	{"start", "Start Time", kindTime, false, func(s Session) any { return s.Start() }},
+	{"end", "End Time", kindTime, false, func(s Session) any { return s.End() }},
+	{"start_unix", "Start (Unix)", kindInt, false, func(s Session) any { return s.startUnix }},
+	{"end_unix", "End (Unix)", kindInt, false, func(s Session) any { return s.endUnix }},
+	{"timezone", "Time Zone", kindText, false, func(s Session) any { return s.TimeZone }},
+	{"duration", "Duration", kindDuration, true, func(s Session) any { return time.Duration(s.Difference) * time.Second }},
+	{"billed_duration", "Billed Duration", kindDuration, true, func(s Session) any { return time.Duration(s.BilledDifference) * time.Second }},
+	{"hourly_rate", "Hourly Rate", kindMoney, false, func(s Session) any { return s.HourlyRate }},
+	{"earnings", "Earnings", kindMoney, true, func(s Session) any { return s.Earnings }},
+	{"currency", "Currency", kindText, false, func(s Session) any { return s.Currency }},
+	{"created_by", "Created By", kindText, false, func(s Session) any { return s.CreatedBy }},
+	{"user_name", "User", kindText, false, func(s Session) any { return s.UserName }},
+	{"user_email", "Email", kindText, false, func(s Session) any { return s.UserEmail }},
+}
+
+// defaultExportColumns are exported when a preset selects none.
+var defaultExportColumns = []string{"title", "description", "project", "start", "end", "duration", "billed_duration", "hourly_rate", "earnings", "currency"}
+
+// ExportPreset is a named set of export options. Presets are stored in the
+// config and can be run from the Export tab or with "taskTracker export".
+type ExportPreset struct {
+	Name             string   `json:"name"`
+	Format           string   `json:"format"` // "csv", "xlsx", "ics" or "template:<file>" (the last two ignore columns)
+	Columns          []string `json:"columns"`
+	DateFormat       string   `json:"date_format,omitempty"`       // Go time layout for CSV
+	DurationFormat   string   `json:"duration_format,omitempty"`   // one of durationFormats, for CSV
+	DecimalSeparator string   `json:"decimal_separator,omitempty"` // "." or ",", for CSV
+	CSVDelimiter     string   `json:"csv_delimiter,omitempty"`     // empty uses the global default
+	Project          string   `json:"project,omitempty"`           // only sessions of this project
+	// From and To are time inputs like "monday" or "2024-01-01"; they are
+	// read when the preset runs, so relative ranges stay relative. Empty From
+	// exports all sessions.
+	From string `json:"from,omitempty"`
+	To   string `json:"to,omitempty"`
+	// RangeMode is "contained" (default), "overlapping" or "clipped".
+	RangeMode string `json:"range_mode,omitempty"`
+}
+
+// validate checks the preset before it is saved or run.
+func (p ExportPreset) validate() error {
+	if _, err := lookupExporter(p.Format); err != nil {
+		return err
+	}
+	if _, ok := parseRangeMode(p.RangeMode); !ok {
+		return fmt.Errorf("unknown range mode %q", p.RangeMode)
+	}
+	if _, err := p.columns(); err != nil {
+		return err
+	}
+	if p.DurationFormat != "" && !slices.Contains(durationFormats, p.DurationFormat) {
+		return fmt.Errorf("unknown duration format %q", p.DurationFormat)
+	}
+	if p.DecimalSeparator != "" && p.DecimalSeparator != "." && p.DecimalSeparator != "," {
+		return fmt.Errorf("decimal separator must be . or ,")
+	}
+	if len([]rune(p.CSVDelimiter)) > 1 {
+		return fmt.Errorf("CSV delimiter must be a single character")
+	}
+	return nil
+}
+
+// columns returns the selected columns in export order.
+func (p ExportPreset) columns() ([]exportColumn, error) {
+	keys := p.Columns
+	if len(keys) == 0 {
+		keys = defaultExportColumns
+	}
+	var cols []exportColumn
+	for _, key := range keys {
+		col, ok := exportColumnByKey(key)
+		if !ok {
+			return nil, fmt.Errorf("unknown export column %q", key)
+		}
+		cols = append(cols, col)
+	}
+	return cols, nil
+}
+
+// timeRange reads From and To relative to now. ok is false when the preset exports everything.
+func (p ExportPreset) timeRange(now time.Time) (from, to time.Time, ok bool, err error) {
+	return parseOptionalRange(p.From, p.To, now, time.Local)
+}
+
+// text formats a column value for CSV.
+func (p ExportPreset) text(col exportColumn, s Session) string {
+	switch v := col.value(s).(type) {
+	case string:
+		return v
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case time.Time:
+		layout := p.DateFormat
+		if layout == "" {
+			layout = displayLayout
+		}
+		return v.Format(layout)
+	case time.Duration:
+		return p.decimal(formatExportDuration(v, p.DurationFormat))
+	case Money:
+		return p.decimal(v.Decimal())
+	}
+	return ""
+}
+
+// decimal applies the decimal separator to a number written with '.'.
+func (p ExportPreset) decimal(s string) string {
+	if p.DecimalSeparator == "," {
+		return strings.Replace(s, ".", ",", 1)
+	}
+	return s
+}
+
+// formatExportDuration writes d in one of durationFormats, defaulting to h:mm:ss.
+func formatExportDuration(d time.Duration, format string) string {
+	switch format {
+	case durationHours:
+		return hours(d)
+	case durationMinutes:
+		return strconv.FormatInt(int64(d/time.Minute), 10)
+	case durationGo:
+		return d.String()
+	}
+	seconds := int64(d / time.Second)
+	sign := ""
+	if seconds < 0 {
+		sign = "-"
+		seconds = -seconds
+	}
+	return fmt.Sprintf("%s%d:%02d:%02d", sign, seconds/3600, seconds/60%60, seconds%60)
+}
+
+// exportColumnByKey looks up a column by its key.
+func exportColumnByKey(key string) (exportColumn, bool) {
+	for _, col := range exportColumns {
+		if col.Key == key {
+			return col, true
+		}
+	}
+	return exportColumn{}, false
+}
+
+// exportFileName suggests a file name for an export.
+func exportFileName(p ExportPreset) string {
+	name := "export"
+	if p.Name != "" {
+		name = strings.ReplaceAll(p.Name, " ", "_")
+	}
+	ext := p.Format
+	if e, err := lookupExporter(p.Format); err == nil {
+		ext = e.Extension()
+	}
+	return name + "_" + time.Now().Format("2006-01-02_15-04-05") + "." + ext
+}
+
+// writeSessionsCSV writes sessions with the preset's columns and formats.
+func writeSessionsCSV(w io.Writer, sessions []Session, p ExportPreset, delimiter rune) error {
+	cols, err := p.columns()
+	if err != nil {
+		return err
+	}
+
+	// write BOM + CSV
+	_, _ = w.Write([]byte{0xEF, 0xBB, 0xBF})
+	writer := csv.NewWriter(w)
+	writer.Comma = delimiter
+
+	header := make([]string, len(cols))
+	for i, col := range cols {
+		header[i] = col.Header
+	}
+	if err := writer.Write(header); err != nil {
+		return fmt.Errorf("writing header: %w", err)
+	}
+	for _, s := range sessions {
+		row := make([]string, len(cols))
+		for i, col := range cols {
+			row[i] = p.text(col, s)
+		}
+		if err := writer.Write(row); err != nil {
+			return fmt.Errorf("writing row: %w", err)
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// runExport writes the sessions selected by p to w and returns how many were exported.
+func runExport(store SessionStore, cfg *Config, p ExportPreset, w io.Writer, now time.Time) (int, error) {
+	if err := p.validate(); err != nil {
+		return 0, err
+	}
+	exporter, err := lookupExporter(p.Format)
+	if err != nil {
+		return 0, err
+	}
+	from, to, _, err := p.timeRange(now)
+	if err != nil {
+		return 0, err
+	}
+	if p.CSVDelimiter == "" {
+		p.CSVDelimiter = string(cfg.csvDelimiter())
+	}
+
+	mode, _ := parseRangeMode(p.RangeMode)
+	sessions, err := store.List(SessionFilter{From: from, To: to, Mode: mode, Project: p.Project})
+	if err != nil {
+		return 0, err
+	}
+	return len(sessions), exporter.Export(w, ExportJob{Sessions: sessions, Options: p, From: from, To: to})
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ExportJob is what an Exporter writes: the selected sessions and the options
+// they were selected with.
+type ExportJob struct {
+	Sessions []Session
+	Options  ExportPreset
+	From, To time.Time // zero when all sessions are exported
+}
+
+// Exporter writes sessions in one file format.
+type Exporter interface {
+	// Extension is the file extension without the dot.
+	Extension() string
+	// Export writes the job to w.
+	Export(w io.Writer, job ExportJob) error
+}
+
+// exporters holds the registered formats; exportFormats keeps their order for the UI.
+var (
+	exporters     = map[string]Exporter{}
+	exportFormats []string
+)
+
+// registerExporter makes an exporter available under a format name.
+func registerExporter(format string, e Exporter) {
+	if _, ok := exporters[format]; !ok {
+		exportFormats = append(exportFormats, format)
+	}
+	exporters[format] = e
+}
+
+func init() {
+	registerExporter(exportCSV, csvExporter{})
+	registerExporter(exportXLSX, xlsxExporter{})
+	registerExporter(exportICS, icsExporter{})
+	registerExporter(exportJSON, jsonExporter{})
+}
+
+// lookupExporter returns the exporter for a format, including "template:<file>" formats.
+func lookupExporter(format string) (Exporter, error) {
+	if name, ok := templateName(format); ok {
+		if name != filepath.Base(name) {
+			return nil, fmt.Errorf("invalid template name %q", name)
+		}
+		if _, err := os.Stat(filepath.Join(templateDir(), name)); err != nil {
+			return nil, fmt.Errorf("export template %q not found in %s", name, templateDir())
+		}
+		return templateExporter{name: name}, nil
+	}
+	e, ok := exporters[format]
+	if !ok {
+		return nil, fmt.Errorf("unknown export format %q", format)
+	}
+	return e, nil
+}
+
+// csvExporter writes the selected columns as CSV with a BOM.
+type csvExporter struct{}
+
+func (csvExporter) Extension() string { return exportCSV }
+
+func (csvExporter) Export(w io.Writer, job ExportJob) error {
+	delimiter := ';'
+	if r := []rune(job.Options.CSVDelimiter); len(r) == 1 {
+		delimiter = r[0]
+	}
+	return writeSessionsCSV(w, job.Sessions, job.Options, delimiter)
+}
+
+// xlsxExporter writes a workbook with typed cells and summary sheets.
+type xlsxExporter struct{}
+
+func (xlsxExporter) Extension() string { return exportXLSX }
+
+func (xlsxExporter) Export(w io.Writer, job ExportJob) error {
+	cols, err := job.Options.columns()
+	if err != nil {
+		return err
+	}
+	return writeSessionsXLSX(w, job.Sessions, cols)
+}
+
+// icsExporter writes one calendar event per session; columns are ignored.
+type icsExporter struct{}
+
+func (icsExporter) Extension() string { return exportICS }
+
+func (icsExporter) Export(w io.Writer, job ExportJob) error {
+	return writeSessionsICS(w, job.Sessions)
+}
+
+// templateExporter runs a user template from templateDir.
+type templateExporter struct {
+	name string
+}
+
+func (e templateExporter) Extension() string { return templateExtension(e.name) }
+
+func (e templateExporter) Export(w io.Writer, job ExportJob) error {
+	return writeTemplateExport(w, e.name, newReportData(job.Sessions, job.From, job.To))
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// update rewrites the golden files with the current output: go test -run TestExporters -update
+var update = flag.Bool("update", false, "rewrite golden files in testdata")
+
+// exportTestSessions returns fixed sessions covering two projects, two
+// currencies, a day with two sessions and text that needs escaping.
+func exportTestSessions() []Session {
+	day := func(d, h, m int) time.Time { return time.Date(2024, 3, d, h, m, 0, 0, time.UTC) }
+	sessions := []Session{
+		newSession("Review; \"urgent\"", "Line one\nline two, with comma", "Website", day(4, 9, 0), day(4, 10, 30), "UTC"),
+		newSession("Review; \"urgent\"", "", "Website", day(4, 13, 15), day(4, 13, 22), "UTC"),
+		newSession("Bookkeeping", "Quarterly report", "", day(5, 8, 0), day(5, 11, 45), "UTC"),
+	}
+	rates := []Money{4550, 4550, 6000}
+	billed := []time.Duration{90 * time.Minute, 15 * time.Minute, 225 * time.Minute}
+	currencies := []string{"EUR", "EUR", "USD"}
+	for i := range sessions {
+		s := &sessions[i]
+		s.ID = i + 1
+		s.uuid = fmt.Sprintf("00000000-0000-4000-8000-00000000000%d", i+1)
+		s.HourlyRate = rates[i]
+		s.Currency = currencies[i]
+		s.CreatedBy = "laptop"
+		s.UserName, s.UserEmail = "Ada", "ada@example.com"
+		s.bill(billed[i])
+	}
+	return sessions
+}
+
+// exportTimestamps matches when an export was made, which changes on every run.
+var exportTimestamps = regexp.MustCompile(`(DTSTAMP:)\d{8}T\d{6}Z|("exported_at": ")[^"]*`)
+
+func TestExporters(t *testing.T) {
+	allColumns := make([]string, len(exportColumns))
+	for i, col := range exportColumns {
+		allColumns[i] = col.Key
+	}
+	tests := []struct {
+		name    string
+		format  string
+		options ExportPreset
+	}{
+		{"csv", exportCSV, ExportPreset{}},
+		{"csv_options", exportCSV, ExportPreset{Columns: allColumns, DateFormat: time.RFC3339, DurationFormat: durationHours, DecimalSeparator: ",", CSVDelimiter: ","}},
+		{"xlsx", exportXLSX, ExportPreset{}},
+		{"xlsx_all_columns", exportXLSX, ExportPreset{Columns: allColumns}},
+		{"ics", exportICS, ExportPreset{}},
+		{"json", exportJSON, ExportPreset{}},
+	}
+
+	tested := map[string]bool{}
+	for _, tt := range tests {
+		tested[tt.format] = true
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := lookupExporter(tt.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var buf bytes.Buffer
+			if err := e.Export(&buf, ExportJob{Sessions: exportTestSessions(), Options: tt.options}); err != nil {
+				t.Fatalf("Export: %v", err)
+			}
+			got := buf.Bytes()
+			if tt.format == exportXLSX {
+				got = xlsxCells(t, got)
+			}
+			got = exportTimestamps.ReplaceAll(got, []byte("${1}${2}NOW"))
+			compareGolden(t, "export_"+tt.name+".golden", got)
+		})
+	}
+	for _, format := range exportFormats {
+		if !tested[format] {
+			t.Errorf("registered format %q has no golden test", format)
+		}
+	}
+}
+
+// xlsxCells lists the sheets of a workbook with the raw value or formula of
+// every cell, one per line; the zip container itself is not reproducible.
+func xlsxCells(t *testing.T, workbook []byte) []byte {
+	t.Helper()
+	f, err := excelize.OpenReader(bytes.NewReader(workbook))
+	if err != nil {
+		t.Fatalf("opening workbook: %v", err)
+	}
+	defer f.Close()
+
+	var buf bytes.Buffer
+	for _, sheet := range f.GetSheetList() {
+		fmt.Fprintf(&buf, "[%s]\n", sheet)
+		// formula cells have no cached value, so GetRows only gives the extent
+		rows, err := f.GetRows(sheet)
+		if err != nil {
+			t.Fatal(err)
+		}
+		width := 0
+		for _, row := range rows {
+			width = max(width, len(row))
+		}
+		for r := 1; r <= len(rows); r++ {
+			for c := 1; c <= width; c++ {
+				cell := cellName(c, r)
+				formula, err := f.GetCellFormula(sheet, cell)
+				if err != nil {
+					t.Fatal(err)
+				}
+				value, err := f.GetCellValue(sheet, cell, excelize.Options{RawCellValue: true})
+				if err != nil {
+					t.Fatal(err)
+				}
+				switch {
+				case formula != "":
+					fmt.Fprintf(&buf, "%s =%s\n", cell, formula)
+				case value != "":
+					fmt.Fprintf(&buf, "%s %q\n", cell, value)
+				}
+			}
+		}
+	}
+	return buf.Bytes()
+}
+
+// compareGolden compares got with testdata/name, or rewrites it with -update.
+func compareGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading golden file (run with -update to create it): %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("output differs from %s (run with -update to accept it)\ngot:\n%s\nwant:\n%s", path, got, want)
+	}
+}
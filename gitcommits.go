@@ -0,0 +1,319 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Commits are read with the git command line tool from the repositories in
+// the settings, so no repository is ever written to.
+
+// gitTimeout limits one git invocation.
+const gitTimeout = 15 * time.Second
+
+// Commit is a commit made in one of the configured repositories.
+type Commit struct {
+	Hash    string
+	Repo    string // repository path as configured
+	Author  string
+	Subject string
+	Time    time.Time // author time
+}
+
+// ShortHash returns the abbreviated hash shown in the UI.
+func (c Commit) ShortHash() string {
+	if len(c.Hash) > 8 {
+		return c.Hash[:8]
+	}
+	return c.Hash
+}
+
+// git runs git in repo and returns its standard output.
+func git(repo string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), gitTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", repo}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s in %s: %s", args[0], repo, msg)
+		}
+		return "", fmt.Errorf("git %s in %s: %w", args[0], repo, err)
+	}
+	return string(out), nil
+}
+
+// gitAuthor returns the author whose commits are read: the configured one,
+// else the repository's user.email. Empty means everyone's commits.
+func gitAuthor(cfg *Config, repo string) string {
+	if cfg.GitAuthor != "" {
+		return cfg.GitAuthor
+	}
+	email, err := git(repo, "config", "user.email")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(email)
+}
+
+// readCommits returns the commits of all branches of repo authored in [from, to].
+func readCommits(repo, author string, from, to time.Time) ([]Commit, error) {
+	// --since compares committer dates, which are never before author dates,
+	// so it only narrows the log; the exact range is checked below
+	args := []string{"log", "--all", "--no-merges",
+		"--since=" + from.UTC().Format(time.RFC3339),
+		"--format=%H%x1f%at%x1f%an%x1f%s%x1e"}
+	if author != "" {
+		args = append(args, "--author="+author)
+	}
+	out, err := git(repo, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var commits []Commit
+	for _, record := range strings.Split(out, "\x1e") {
+		fields := strings.Split(strings.TrimSpace(record), "\x1f")
+		if len(fields) != 4 {
+			continue
+		}
+		unix, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		t := time.Unix(unix, 0)
+		if t.Before(from) || t.After(to) {
+			continue
+		}
+		commits = append(commits, Commit{Hash: fields[0], Repo: repo, Author: fields[2], Subject: fields[3], Time: t})
+	}
+	return commits, nil
+}
+
+// commitsBetween reads the commits of all configured repositories, oldest
+// first. A failing repository does not hide the commits of the others; its
+// error is returned alongside them.
+func commitsBetween(cfg *Config, from, to time.Time) ([]Commit, error) {
+	var commits []Commit
+	var errs []error
+	for _, repo := range cfg.GitRepos {
+		c, err := readCommits(repo, gitAuthor(cfg, repo), from, to)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		commits = append(commits, c...)
+	}
+	sort.SliceStable(commits, func(i, j int) bool { return commits[i].Time.Before(commits[j].Time) })
+	return commits, errors.Join(errs...)
+}
+
+// commitRange identifies the commits read for a span of time with the
+// repositories and author in the settings at the time.
+type commitRange struct {
+	from, to int64
+	repos    string
+	author   string
+}
+
+func newCommitRange(cfg *Config, from, to time.Time) commitRange {
+	return commitRange{from: from.Unix(), to: to.Unix(), repos: strings.Join(cfg.GitRepos, "\n"), author: cfg.GitAuthor}
+}
+
+// commitResult is what commitsBetween returned for a commitRange.
+type commitResult struct {
+	commits []Commit
+	err     error
+}
+
+// readCommitsAsync runs commitsBetween in the background, so the UI does not
+// wait for git, and calls done with the result on the UI goroutine.
+func readCommitsAsync(cfg *Config, from, to time.Time, done func(commitResult)) {
+	// the settings may change while git runs
+	snapshot := &Config{GitRepos: slices.Clone(cfg.GitRepos), GitAuthor: cfg.GitAuthor}
+	go func() {
+		commits, err := commitsBetween(snapshot, from, to)
+		fyne.Do(func() {
+			done(commitResult{commits: commits, err: err})
+		})
+	}()
+}
+
+// sessionsSpan returns the earliest start and the latest end of sessions.
+func sessionsSpan(sessions []Session) (from, to time.Time) {
+	for i, s := range sessions {
+		if i == 0 || s.Start().Before(from) {
+			from = s.Start()
+		}
+		if i == 0 || s.End().After(to) {
+			to = s.End()
+		}
+	}
+	return from, to
+}
+
+// attachCommits returns the commits made during each session, by session ID.
+func attachCommits(sessions []Session, commits []Commit) map[int][]Commit {
+	attached := map[int][]Commit{}
+	for _, s := range sessions {
+		for _, c := range commits {
+			if u := c.Time.Unix(); u >= s.startUnix && u <= s.endUnix {
+				attached[s.ID] = append(attached[s.ID], c)
+			}
+		}
+	}
+	return attached
+}
+
+// commitLines formats commits for a session card, at most limit of them.
+func commitLines(commits []Commit, limit int) string {
+	var lines []string
+	for i, c := range commits {
+		if i == limit {
+			lines = append(lines, fmt.Sprintf("... and %d more", len(commits)-limit))
+			break
+		}
+		lines = append(lines, c.ShortHash()+" "+c.Subject+" ("+filepath.Base(c.Repo)+")")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Suggestions group commits that are at most suggestionGap apart. Work starts
+// before the first commit of a cluster, so suggestions begin suggestionLead
+// earlier.
+const (
+	suggestionGap  = 2 * time.Hour
+	suggestionLead = 30 * time.Minute
+)
+
+// Suggestion is a session proposed from a cluster of commits.
+type Suggestion struct {
+	Title       string
+	Description string
+	Start, End  time.Time
+	Commits     []Commit
+}
+
+// suggestSessions clusters commits (oldest first) and proposes a session for
+// every cluster that no tracked session overlaps, i.e. for work that was not tracked.
+func suggestSessions(commits []Commit, tracked []Session) []Suggestion {
+	var clusters [][]Commit
+	for i, c := range commits {
+		if i == 0 || c.Time.Sub(commits[i-1].Time) > suggestionGap {
+			clusters = append(clusters, nil)
+		}
+		clusters[len(clusters)-1] = append(clusters[len(clusters)-1], c)
+	}
+
+	var suggestions []Suggestion
+	for _, cluster := range clusters {
+		start := cluster[0].Time.Add(-suggestionLead)
+		end := cluster[len(cluster)-1].Time
+		if overlapsTracked(start, end, tracked) {
+			continue
+		}
+
+		var repos, subjects []string
+		for _, c := range cluster {
+			if name := filepath.Base(c.Repo); !slices.Contains(repos, name) {
+				repos = append(repos, name)
+			}
+			subjects = append(subjects, c.Subject)
+		}
+		suggestions = append(suggestions, Suggestion{
+			Title:       strings.Join(repos, ", "),
+			Description: strings.Join(subjects, "\n"),
+			Start:       start,
+			End:         end,
+			Commits:     cluster,
+		})
+	}
+	return suggestions
+}
+
+// overlapsTracked reports whether [start, end] overlaps one of the sessions.
+func overlapsTracked(start, end time.Time, tracked []Session) bool {
+	for _, s := range tracked {
+		if s.startUnix <= end.Unix() && s.endUnix >= start.Unix() {
+			return true
+		}
+	}
+	return false
+}
+
+// newCommitSuggestions lists the sessions suggested for a range; save stores
+// the ones the user picks.
+func newCommitSuggestions(cfg *Config, store SessionStore, save func(s Suggestion) error) fyne.CanvasObject {
+	statusLabel := widget.NewLabel("")
+	rangeStart := newTimeEntry("Range start (empty: last 7 days)")
+	rangeEnd := newTimeEntry("Range end (empty: now)")
+	list := container.NewVBox()
+
+	findBtn := widget.NewButton("Suggest sessions from git commits", func() {
+		list.RemoveAll()
+		if len(cfg.GitRepos) == 0 {
+			statusLabel.SetText("Add git repositories in the settings first")
+			return
+		}
+		now := time.Now()
+		from, to, ranged, err := parseOptionalRange(rangeStart.Text, rangeEnd.Text, now, time.Local)
+		if err != nil {
+			statusLabel.SetText("Invalid time range: " + err.Error())
+			return
+		}
+		if !ranged {
+			from, to = now.AddDate(0, 0, -7), now
+		}
+
+		tracked, err := store.List(SessionFilter{From: from, To: to, Mode: rangeOverlapping})
+		if err != nil {
+			showError("reading sessions", err)
+			return
+		}
+		commits, err := commitsBetween(cfg, from, to)
+		suggestions := suggestSessions(commits, tracked)
+		if err != nil {
+			statusLabel.SetText("Some repositories could not be read: " + err.Error())
+		} else {
+			statusLabel.SetText(fmt.Sprintf("%d suggestions from %d commits", len(suggestions), len(commits)))
+		}
+		for _, sg := range suggestions {
+			var addBtn *widget.Button
+			addBtn = widget.NewButton("Add session", func() {
+				if err := save(sg); err != nil {
+					showError("saving session", err)
+					return
+				}
+				addBtn.SetText("Added")
+				addBtn.Disable()
+			})
+			list.Add(widget.NewCard(sg.Title, sg.Start.Format(displayLayout)+" - "+sg.End.Format("15:04"), container.NewVBox(
+				widget.NewLabel(commitLines(sg.Commits, maxCardCommits)),
+				addBtn,
+			)))
+		}
+	})
+
+	return container.NewVBox(
+		widget.NewSeparator(),
+		container.NewGridWithColumns(2, rangeStart, rangeEnd),
+		findBtn,
+		statusLabel,
+		list,
+	)
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"time"
+)
+
+// Hooks are executables in hookDir named after the event they run on. They
+// get the session in TASKTRACKER_* environment variables and the webhook
+// payload as JSON on stdin; their output goes to the log.
+
+// hookNames maps events to the hook executables run on them.
+var hookNames = map[string]string{
+	eventTimerStarted:   "on-start",
+	eventTimerStopped:   "on-stop",
+	eventSessionCreated: "on-save",
+}
+
+const (
+	// hookTimeout is how long a hook may run before it is killed.
+	hookTimeout = 30 * time.Second
+	// maxHookOutput is how much of a hook's output is logged.
+	maxHookOutput = 16 << 10
+)
+
+// hookDir returns the folder searched for hook executables.
+func hookDir() string {
+	return filepath.Join(appConfigDir(), "hooks")
+}
+
+// findHook returns the executable for name in hookDir, or "" if there is none.
+// Windows has no executable bit, so there the usual extensions are tried.
+func findHook(name string) string {
+	candidates := []string{name}
+	if runtime.GOOS == "windows" {
+		candidates = []string{name + ".exe", name + ".bat", name + ".cmd"}
+	}
+	for _, c := range candidates {
+		path := filepath.Join(hookDir(), c)
+		info, err := os.Stat(path)
+		if err != nil {
+			if !errors.Is(err, fs.ErrNotExist) {
+				slog.Warn("reading hook", "path", path, "err", err)
+			}
+			continue
+		}
+		if info.IsDir() {
+			continue
+		}
+		if runtime.GOOS != "windows" && info.Mode()&0111 == 0 {
+			slog.Warn("hook is not executable", "path", path)
+			continue
+		}
+		return path
+	}
+	return ""
+}
+
+// hookEnv returns the environment variables describing payload.
+func hookEnv(p webhookPayload) []string {
+	s := p.Session
+	return []string{
+		"TASKTRACKER_EVENT=" + p.Event,
+		"TASKTRACKER_SESSION_ID=" + strconv.Itoa(s.ID),
+		"TASKTRACKER_SESSION_UUID=" + s.UUID,
+		"TASKTRACKER_TITLE=" + s.Title,
+		"TASKTRACKER_DESCRIPTION=" + s.Description,
+		"TASKTRACKER_PROJECT=" + s.Project,
+		"TASKTRACKER_START=" + s.Start,
+		"TASKTRACKER_END=" + s.End,
+		"TASKTRACKER_DURATION_SECONDS=" + strconv.FormatInt(s.DurationSecs, 10),
+		"TASKTRACKER_BILLED_SECONDS=" + strconv.FormatInt(s.BilledSecs, 10),
+		"TASKTRACKER_HOURLY_RATE=" + s.HourlyRate,
+		"TASKTRACKER_EARNINGS=" + s.Earnings,
+		"TASKTRACKER_CURRENCY=" + s.Currency,
+		"TASKTRACKER_CREATED_BY=" + s.CreatedBy,
+		"TASKTRACKER_USER_NAME=" + s.UserName,
+		"TASKTRACKER_USER_EMAIL=" + s.UserEmail,
+	}
+}
+
+// cappedBuffer keeps the first max bytes written to it and drops the rest.
+type cappedBuffer struct {
+	bytes.Buffer
+	max       int
+	truncated bool
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	if room := b.max - b.Len(); len(p) > room {
+		b.Buffer.Write(p[:max(room, 0)])
+		b.truncated = true
+		return len(p), nil
+	}
+	return b.Buffer.Write(p)
+}
+
+// runHook starts the hook of event, if there is one, in the background.
+func runHook(event string, s Session) {
+	name, ok := hookNames[event]
+	if !ok {
+		return
+	}
+	path := findHook(name)
+	if path == "" {
+		return
+	}
+	p := newWebhookPayload(event, s)
+	body, err := json.Marshal(p)
+	if err != nil {
+		slog.Error("encoding hook input", "hook", name, "err", err)
+		return
+	}
+	go execHook(path, hookEnv(p), body)
+}
+
+// execHook runs the hook at path and logs how it went.
+func execHook(path string, env []string, input []byte) {
+	ctx, cancel := context.WithTimeout(context.Background(), hookTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, path)
+	cmd.Dir = hookDir()
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stdin = bytes.NewReader(input)
+	out := &cappedBuffer{max: maxHookOutput}
+	cmd.Stdout = out
+	cmd.Stderr = out
+	// children that keep the output open must not hold up the hook forever
+	cmd.WaitDelay = time.Second
+
+	started := time.Now()
+	err := cmd.Run()
+	attrs := []any{"hook", filepath.Base(path), "took", time.Since(started).Round(time.Millisecond), "output", out.String()}
+	if out.truncated {
+		attrs = append(attrs, "truncated", true)
+	}
+	switch {
+	case ctx.Err() != nil:
+		slog.Warn("hook timed out", append(attrs, "timeout", hookTimeout)...)
+	case err != nil:
+		slog.Warn("hook failed", append(attrs, "err", err)...)
+	default:
+		slog.Info("hook ran", attrs...)
+	}
+}
@@ -0,0 +1,423 @@
+package main
+
+import (
+	"bufio"
+	"database/sql"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+)
+
+// icsTimeLayout is the iCalendar UTC date-time form.
+const icsTimeLayout = "20060102T150405Z"
+
+// icsUUIDSpace derives session uuids from event UIDs that are not uuids,
+// so importing the same event twice is detected.
+var icsUUIDSpace = uuid.MustParse("6f1c2a52-5a3e-4d8b-9f0e-2b7c1d9e4a10")
+
+// icsEvent is a VEVENT read from a calendar file.
+type icsEvent struct {
+	UID         string
+	Summary     string
+	Description string
+	Start, End  time.Time
+	Zone        string // IANA zone from TZID, "" for UTC or floating times
+	AllDay      bool
+	RRule       string      // recurrence rule as written, "" for single events
+	RDates      []time.Time // further occurrences
+	ExDates     []time.Time // occurrences left out
+}
+
+// sessionUUID returns the session uuid for the event: its UID if that is a
+// uuid (e.g. exported by TaskTracker), otherwise one derived from the UID.
+// Events without a UID get one derived from their times and summary, so
+// they are told apart from each other but still detected on a second import.
+func (e icsEvent) sessionUUID() string {
+	if id, err := uuid.Parse(e.UID); err == nil {
+		return id.String()
+	}
+	name := e.UID
+	if name == "" {
+		name = e.Start.UTC().Format(icsTimeLayout) + "/" + e.End.UTC().Format(icsTimeLayout) + "/" + e.Summary
+	}
+	return uuid.NewSHA1(icsUUIDSpace, []byte(name)).String()
+}
+
+// writeSessionsICS writes sessions as an iCalendar file with one VEVENT each.
+// Times are written in UTC; the UID is the session uuid.
+func writeSessionsICS(w io.Writer, sessions []Session) error {
+	bw := bufio.NewWriter(w)
+	line := func(name, value string) {
+		writeICSLine(bw, name+":"+value)
+	}
+
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", "-//TaskTracker//Sessions//EN")
+	line("CALSCALE", "GREGORIAN")
+	stamp := time.Now().UTC().Format(icsTimeLayout)
+	for _, s := range sessions {
+		line("BEGIN", "VEVENT")
+		line("UID", s.uuid)
+		line("DTSTAMP", stamp)
+		line("DTSTART", time.Unix(s.startUnix, 0).UTC().Format(icsTimeLayout))
+		line("DTEND", time.Unix(s.endUnix, 0).UTC().Format(icsTimeLayout))
+		line("SUMMARY", escapeICSText(s.Title))
+		if s.Description != "" {
+			line("DESCRIPTION", escapeICSText(s.Description))
+		}
+		if s.Project != "" {
+			line("CATEGORIES", escapeICSText(s.Project))
+		}
+		line("END", "VEVENT")
+	}
+	line("END", "VCALENDAR")
+	return bw.Flush()
+}
+
+// writeICSLine writes a content line, folded at 75 octets as RFC 5545 requires.
+func writeICSLine(w *bufio.Writer, s string) {
+	limit := 75
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		w.WriteString(s[:cut] + "\r\n ")
+		s = s[cut:]
+		limit = 74 // continuation lines start with a space
+	}
+	w.WriteString(s + "\r\n")
+}
+
+// escapeICSText escapes a TEXT value.
+func escapeICSText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
+
+// unescapeICSText reverses escapeICSText.
+func unescapeICSText(s string) string {
+	return strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n").Replace(s)
+}
+
+// parseICS reads the VEVENTs of a calendar. Start and End are the first
+// occurrence; the recurrence is kept as written for callers that expand it.
+func parseICS(r io.Reader) ([]icsEvent, error) {
+	// unfold continuation lines
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		l := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(l, " ") || strings.HasPrefix(l, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += l[1:]
+			continue
+		}
+		lines = append(lines, l)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	var events []icsEvent
+	var ev *icsEvent
+	var duration time.Duration
+	depth := 0 // nesting inside the event, e.g. VALARM
+	for n, l := range lines {
+		name, params, value := splitICSLine(l)
+		switch {
+		case name == "BEGIN" && value == "VEVENT":
+			ev, duration, depth = &icsEvent{}, 0, 0
+		case ev == nil:
+			continue
+		case name == "BEGIN":
+			depth++
+		case name == "END" && value != "VEVENT":
+			depth--
+		case name == "END":
+			if ev.Start.IsZero() {
+				return nil, fmt.Errorf("line %d: event %q has no DTSTART", n+1, ev.Summary)
+			}
+			if ev.End.IsZero() {
+				switch {
+				case duration > 0:
+					ev.End = ev.Start.Add(duration)
+				case ev.AllDay:
+					ev.End = ev.Start.AddDate(0, 0, 1)
+				default:
+					ev.End = ev.Start
+				}
+			}
+			events = append(events, *ev)
+			ev = nil
+		case depth > 0:
+			continue
+		case name == "UID":
+			ev.UID = value
+		case name == "SUMMARY":
+			ev.Summary = unescapeICSText(value)
+		case name == "DESCRIPTION":
+			ev.Description = unescapeICSText(value)
+		case name == "DTSTART" || name == "DTEND":
+			t, zone, allDay, err := parseICSTime(value, params)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", n+1, err)
+			}
+			if name == "DTSTART" {
+				ev.Start, ev.Zone, ev.AllDay = t, zone, allDay
+			} else {
+				ev.End = t
+			}
+		case name == "RRULE":
+			ev.RRule = value
+		case name == "RDATE" || name == "EXDATE":
+			for _, v := range strings.Split(value, ",") {
+				t, _, _, err := parseICSTime(v, params)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: %w", n+1, err)
+				}
+				if name == "RDATE" {
+					ev.RDates = append(ev.RDates, t)
+				} else {
+					ev.ExDates = append(ev.ExDates, t)
+				}
+			}
+		case name == "DURATION":
+			d, err := parseICSDuration(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", n+1, err)
+			}
+			duration = d
+		}
+	}
+	return events, nil
+}
+
+// splitICSLine splits "NAME;PARAM=x:value" into its parts. Colons inside
+// quoted parameter values do not end the name.
+func splitICSLine(l string) (name string, params map[string]string, value string) {
+	inQuotes := false
+	colon := -1
+	for i, c := range l {
+		if c == '"' {
+			inQuotes = !inQuotes
+		}
+		if c == ':' && !inQuotes {
+			colon = i
+			break
+		}
+	}
+	if colon < 0 {
+		return strings.ToUpper(l), nil, ""
+	}
+	parts := strings.Split(l[:colon], ";")
+	params = map[string]string{}
+	for _, p := range parts[1:] {
+		k, v, _ := strings.Cut(p, "=")
+		params[strings.ToUpper(k)] = strings.Trim(v, `"`)
+	}
+	return strings.ToUpper(parts[0]), params, l[colon+1:]
+}
+
+// parseICSTime reads a DATE or DATE-TIME value. Times with an unknown TZID
+// (e.g. Windows zone names) are read as local time.
+func parseICSTime(value string, params map[string]string) (t time.Time, zone string, allDay bool, err error) {
+	if params["VALUE"] == "DATE" || len(value) == 8 {
+		t, err = time.ParseInLocation("20060102", value, time.Local)
+		return t, "", true, err
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err = time.Parse(icsTimeLayout, value)
+		return t, "", false, err
+	}
+	loc := time.Local
+	if tzid := params["TZID"]; tzid != "" {
+		if l, lerr := time.LoadLocation(tzid); lerr == nil {
+			loc, zone = l, tzid
+		}
+	}
+	t, err = time.ParseInLocation("20060102T150405", value, loc)
+	return t, zone, false, err
+}
+
+// icsDurationPattern matches durations like P1D, PT1H30M or P1W.
+var icsDurationPattern = regexp.MustCompile(`^([+-])?P(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+
+// parseICSDuration reads a DURATION value.
+func parseICSDuration(value string) (time.Duration, error) {
+	m := icsDurationPattern.FindStringSubmatch(value)
+	if m == nil || value == "P" || value == "PT" {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+	units := []time.Duration{7 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute, time.Second}
+	var d time.Duration
+	for i, unit := range units {
+		if m[i+2] == "" {
+			continue
+		}
+		n, _ := strconv.Atoi(m[i+2])
+		d += time.Duration(n) * unit
+	}
+	if m[1] == "-" {
+		d = -d
+	}
+	return d, nil
+}
+
+// importICSEvents saves events as sessions with the given rate, project and
+// currency. Events imported before (same uuid) and events ending before they
+// start are skipped.
+func importICSEvents(db *sql.DB, store SessionStore, cfg *Config, events []icsEvent, project string, rate Money, currency string) (imported, skipped int, err error) {
+	for _, e := range events {
+		if e.End.Before(e.Start) {
+			skipped++
+			continue
+		}
+		id := e.sessionUUID()
+		exists, err := sessionExists(store, id)
+		if err != nil {
+			return imported, skipped, err
+		}
+		if exists {
+			skipped++
+			continue
+		}
+
+		duration := e.End.Sub(e.Start)
+		billed, err := billedDuration(db, cfg, project, duration)
+		if err != nil {
+			return imported, skipped, err
+		}
+		s := newSession(e.Summary, e.Description, project, e.Start, e.End, e.Zone)
+		s.uuid = id
+		s.HourlyRate = rate
+		s.Currency = currency
+		s.bill(billed)
+		if err := store.Create(&s); err != nil {
+			return imported, skipped, err
+		}
+		imported++
+	}
+	return imported, skipped, nil
+}
+
+// createImportTab imports calendar events from an .ics file as sessions.
+func createImportTab(db *sql.DB, store SessionStore, cfg *Config) fyne.CanvasObject {
+	statusLabel := widget.NewLabel("Import calendar events (.ics) as sessions")
+	var events []icsEvent
+	var shown []icsEvent
+	var checks []*widget.Check
+	eventList := container.NewVBox()
+
+	startEntry := newTimeEntry("Range start (empty: all events)")
+	endEntry := newTimeEntry("Range end (empty: now)")
+	projectEntry := widget.NewSelectEntry(getProjectNames(db))
+	projectEntry.SetPlaceHolder("Project (optional)")
+	rateEntry := widget.NewEntry()
+	rateEntry.SetPlaceHolder("Hourly rate")
+	rateEntry.SetText(cfg.defaultRateText())
+	currencySelect := widget.NewSelect(currencyCodes, nil)
+	currencySelect.SetSelected(cfg.Currency)
+	projectEntry.OnChanged = func(name string) {
+		currencySelect.SetSelected(currencyFor(db, cfg, name))
+	}
+
+	// showEvents lists the loaded events inside the range, all selected
+	showEvents := func() {
+		shown, checks = nil, nil
+		eventList.RemoveAll()
+
+		from, to, ranged, err := parseOptionalRange(startEntry.Text, endEntry.Text, time.Now(), time.Local)
+		if err != nil {
+			statusLabel.SetText("Invalid time range: " + err.Error())
+			return
+		}
+		for _, e := range events {
+			if e.AllDay {
+				continue
+			}
+			if ranged && (e.Start.Before(from) || e.End.After(to)) {
+				continue
+			}
+			label := fmt.Sprintf("%s - %s  %s", e.Start.Local().Format(displayLayout), e.End.Local().Format("15:04"), e.Summary)
+			check := widget.NewCheck(label, nil)
+			check.SetChecked(true)
+			shown = append(shown, e)
+			checks = append(checks, check)
+			eventList.Add(check)
+		}
+		statusLabel.SetText(fmt.Sprintf("%d events in range (all-day events are not listed)", len(shown)))
+	}
+
+	openBtn := widget.NewButton("Open .ics file...", func() {
+		parent := fyne.CurrentApp().Driver().AllWindows()[0]
+		fd := dialog.NewFileOpen(func(r fyne.URIReadCloser, err error) {
+			if r == nil {
+				return // user cancelled
+			}
+			defer r.Close()
+
+			parsed, err := parseICS(r)
+			if err != nil {
+				statusLabel.SetText("Error reading " + r.URI().Name() + ": " + err.Error())
+				return
+			}
+			events = parsed
+			showEvents()
+		}, parent)
+		fd.SetFilter(storage.NewExtensionFileFilter([]string{".ics"}))
+		fd.Show()
+	})
+	filterBtn := widget.NewButton("Apply range", showEvents)
+
+	importBtn := widget.NewButton("Import selected", func() {
+		rate, err := parseMoney(rateEntry.Text)
+		if err != nil {
+			statusLabel.SetText("Invalid hourly rate!")
+			return
+		}
+		var selected []icsEvent
+		for i, check := range checks {
+			if check.Checked {
+				selected = append(selected, shown[i])
+			}
+		}
+		if len(selected) == 0 {
+			statusLabel.SetText("No events selected")
+			return
+		}
+		imported, skipped, err := importICSEvents(db, store, cfg, selected, projectEntry.Text, rate, currencySelect.Selected)
+		if err != nil {
+			statusLabel.SetText(fmt.Sprintf("Error importing events (%d imported): %s", imported, err.Error()))
+			return
+		}
+		statusLabel.SetText(fmt.Sprintf("Imported %d events, skipped %d imported before or ending before they start", imported, skipped))
+	})
+
+	return container.NewBorder(
+		container.NewVBox(
+			statusLabel,
+			openBtn,
+			startEntry,
+			endEntry,
+			filterBtn,
+			projectEntry,
+			container.NewBorder(nil, nil, nil, currencySelect, rateEntry),
+			importBtn,
+		),
+		nil, nil, nil,
+		container.NewVScroll(eventList),
+	)
+}
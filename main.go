@@ -2,18 +2,18 @@ package main
 
 import (
 	"database/sql"
-	"encoding/csv"
+	"errors"
+	"flag"
 	"fmt"
 	"image/color"
-	"math"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
-	"github.com/google/uuid"
-	"github.com/xuri/excelize/v2"
 	_ "modernc.org/sqlite"
 
 	"fyne.io/fyne/v2"
@@ -21,64 +21,292 @@ import (
 	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/data/binding"
-	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 )
 
 // Session represents one work session stored in the database.
-// Difference is stored as seconds (int64) and Earnings as float64.
+// Difference is the raw duration and BilledDifference the rounded duration,
+// both stored as seconds (int64). Earnings is based on the billed duration and,
+// like HourlyRate, is Money (integer cents) in Currency (ISO 4217 code).
+// startUnix/endUnix are the canonical UTC instants and TimeZone the IANA zone the
+// session was recorded in; StartTime/EndTime are display strings in that zone.
+// UserName/UserEmail identify the person who tracked it, CreatedBy the device.
 type Session struct {
-	ID          int
-	uuid        string
+	ID               int
+	uuid             string
+	Title            string
+	Description      string
+	Project          string
+	StartTime        string
+	EndTime          string
+	startUnix        int64
+	endUnix          int64
+	TimeZone         string
+	Difference       int64
+	BilledDifference int64
+	HourlyRate       Money
+	Earnings         Money
+	Currency         string
+	CreatedBy        string
+	UserName         string
+	UserEmail        string
+}
+
+// Start returns the session start in the zone it was recorded in.
+func (s Session) Start() time.Time {
+	return time.Unix(s.startUnix, 0).In(zoneLocation(s.TimeZone))
+}
+
+// End returns the session end in the zone it was recorded in.
+func (s Session) End() time.Time {
+	return time.Unix(s.endUnix, 0).In(zoneLocation(s.TimeZone))
+}
+
+// Duration returns the tracked duration.
+func (s Session) Duration() time.Duration {
+	return time.Duration(s.Difference) * time.Second
+}
+
+// Billed returns the billed (rounded) duration.
+func (s Session) Billed() time.Duration {
+	return time.Duration(s.BilledDifference) * time.Second
+}
+
+// RecentTask is a distinct title/description/project/rate/currency combination used for quick restarts.
+type RecentTask struct {
 	Title       string
 	Description string
-	StartTime   string
-	EndTime     string
-	startUnix   int64
-	endUnix     int64
-	Difference  int64
-	HourlyRate  float64
-	Earnings    float64
-	CreatedBy   string
+	Project     string
+	HourlyRate  Money
+	Currency    string
 }
 
+// maxRecentTasks limits how many quick restart buttons are shown on the timer tab.
+const maxRecentTasks = 5
+
+// maxCardCommits limits how many commits a session card lists.
+const maxCardCommits = 10
+
 func main() {
+	// structured log in the config directory
+	logFile := setupLogging()
+	defer logFile.Close()
+
+	// command line subcommands run without a window
+	if len(os.Args) > 1 && isCommand(os.Args[1]) {
+		if err := runCommand(os.Args[1:], os.Stdout); err != nil {
+			slog.Error("command failed", "args", os.Args[1:], "err", err)
+			fmt.Fprintln(os.Stderr, "taskTracker:", err)
+			logFile.Close()
+			os.Exit(1)
+		}
+		return
+	}
+
+	// --workspace or --db choose the database of the window
+	fs := flag.NewFlagSet("taskTracker", flag.ContinueOnError)
+	workspaceFlag, dbFlag := databaseFlags(fs)
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		logFile.Close()
+		os.Exit(2)
+	}
+
 	// create application and main window
 	myApp := app.New()
 	myWindow := myApp.NewWindow("TaskTracker")
 	myWindow.Resize(fyne.NewSize(800, 600))
 
-	// open sqlite database (modernc.org/sqlite driver)
-	userConfigDir, _ := os.UserConfigDir()
-	dbPath := filepath.Join(userConfigDir, "TaskTracker", "taskTracker.db")
-	os.MkdirAll(filepath.Dir(dbPath), 0755)
-	db, err := sql.Open("sqlite", dbPath)
+	// load settings (a missing file gives defaults, a broken one is reported)
+	cfg, err := loadConfig(filepath.Join(appConfigDir(), "config.json"))
 	if err != nil {
-		panic(err)
+		showError("loading settings", err)
 	}
 
-	defer db.Close()
+	applySettings(myApp, cfg)
 
-	// ensure table exists
-	createTable(db)
+	// the metrics endpoint outlives workspace switches and follows the open one
+	metrics := &metricsSource{cfg: cfg}
+	if cfg.MetricsAddr != "" {
+		if err := serveMetrics(cfg.MetricsAddr, metrics); err != nil {
+			showError("starting metrics endpoint", err)
+		}
+	}
 
-	// create application tabs and set content
-	tabs := container.NewAppTabs(
-		container.NewTabItem("Timer", createTimerTab(db)),
-		container.NewTabItem("Sessions", createSessionsTab(db)),
-		container.NewTabItem("Add", createAddSessionTab(db)),
-		container.NewTabItem("Edit", createEditSessionTab(db)),
-		container.NewTabItem("Delete", createDeleteSessionTab(db)),
-		container.NewTabItem("Export", exportSessions(db)),
-	)
+	// the tabs are built once the database passed its startup check;
+	// switching workspaces closes the database and builds them again
+	var db *sql.DB
+	defer func() {
+		if db != nil {
+			db.Close()
+		}
+	}()
+	// closeWorkspace saves the timer and stops the background work of the
+	// open workspace while its database is still open
+	var closeWorkspace func() error
+	var openWorkspace func(name, path string)
+	openWorkspace = func(name, path string) {
+		if db != nil {
+			db.Close()
+			db = nil
+		}
+		switch name {
+		case defaultWorkspace:
+			myWindow.SetTitle("TaskTracker")
+		case "":
+			myWindow.SetTitle("TaskTracker - " + filepath.Base(path))
+		default:
+			myWindow.SetTitle("TaskTracker - " + name)
+		}
+		startDatabase(myWindow, path, func(opened *sql.DB) {
+			db = opened
+			showUnlock(myWindow, db, path, func(fc *fieldCipher) {
+				// webhooks and hooks see the plain sessions, after decryption
+				webhooks := newWebhookNotifier(db, cfg)
+				notify := func(event string, s Session) {
+					webhooks.notify(event, s)
+					runHook(event, s)
+					metrics.notify(event, s)
+				}
+				store := &notifyingStore{
+					SessionStore: &identityStore{
+						SessionStore: &encryptedStore{inner: newSQLiteStore(db), cipher: fc},
+						cfg:          cfg,
+					},
+					notify: notify,
+				}
+				metrics.setStore(store, fc)
+				timerTab, finishTimer := createTimerTab(db, store, cfg, notify)
+				closeWorkspace = func() error {
+					if err := finishTimer(); err != nil {
+						return err
+					}
+					metrics.setStore(nil, nil)
+					webhooks.close()
+					return nil
+				}
+				switchWorkspace := func(next string) {
+					nextPath, ok := cfg.workspacePath(next)
+					if !ok {
+						showError("switching workspace", fmt.Errorf("no workspace named %q", next))
+						return
+					}
+					if closeWorkspace != nil {
+						if err := closeWorkspace(); err != nil {
+							showError("saving the running timer", err)
+							return
+						}
+						closeWorkspace = nil
+					}
+					rememberWorkspace(cfg, next)
+					openWorkspace(next, nextPath)
+				}
+
+				// create application tabs and set content
+				tabs := container.NewAppTabs(
+					container.NewTabItem("Timer", timerTab),
+					container.NewTabItem("Sessions", createSessionsTab(store, cfg)),
+					container.NewTabItem("Add", createAddSessionTab(db, store, cfg)),
+					container.NewTabItem("Edit", createEditSessionTab(db, store, cfg)),
+					container.NewTabItem("Delete", createDeleteSessionTab(store)),
+					container.NewTabItem("Projects", createProjectsTab(db)),
+					container.NewTabItem("Export", exportSessions(db, store, cfg)),
+					container.NewTabItem("Import", createImportTab(db, store, cfg)),
+					container.NewTabItem("Reports", createReportsTab(store, cfg)),
+					container.NewTabItem("Team", createTeamTab(db, fc, store, cfg)),
+					container.NewTabItem("Working time", createWorkTimeTab(db, store, cfg)),
+					container.NewTabItem("Security", createSecurityTab(db, fc, cfg, switchWorkspace)),
+					container.NewTabItem("Webhooks", createWebhooksTab(db, cfg)),
+					container.NewTabItem("Settings", createSettingsTab(cfg)),
+				)
+				bar := newWorkspaceBar(cfg, name, path, switchWorkspace)
+				myWindow.SetContent(container.NewBorder(bar, nil, nil, nil, tabs))
+			})
+		})
+	}
+
+	name, path, err := cfg.resolveDatabase(*workspaceFlag, *dbFlag)
+	if err != nil {
+		showError("choosing database", err)
+		name, path, _ = cfg.resolveDatabase("", "")
+	}
+	if name != "" {
+		rememberWorkspace(cfg, name)
+	}
+	openWorkspace(name, path)
 
-	myWindow.SetContent(tabs)
 	myWindow.ShowAndRun()
 }
 
+// rememberWorkspace stores the workspace to open on the next start.
+func rememberWorkspace(cfg *Config, name string) {
+	if cfg.Workspace == name {
+		return
+	}
+	cfg.Workspace = name
+	if err := cfg.save(); err != nil {
+		showError("saving settings", err)
+	}
+}
+
+// busyTimeout is how long SQLite retries a statement while another
+// connection or program holds a lock, instead of failing with SQLITE_BUSY.
+const busyTimeout = 5 * time.Second
+
+// openSQLite opens the sqlite database at path (modernc.org/sqlite driver).
+// Every connection waits up to busyTimeout for locks, and transactions take
+// the write lock when they begin, so they wait too instead of failing later.
+func openSQLite(path string) (*sql.DB, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	dsn := fmt.Sprintf("%s?_pragma=busy_timeout(%d)&_txlock=immediate", path, busyTimeout.Milliseconds())
+	return sql.Open("sqlite", dsn)
+}
+
+// prepareSchema creates missing tables and migrates older databases.
+func prepareSchema(db *sql.DB) error {
+	if err := createTable(db); err != nil {
+		return err
+	}
+	if err := createProjectsTable(db); err != nil {
+		return err
+	}
+	if err := createEncryptionTable(db); err != nil {
+		return err
+	}
+	if err := createWebhookTable(db); err != nil {
+		return err
+	}
+	if err := createTeamTable(db); err != nil {
+		return err
+	}
+	if err := createWorkTimeTables(db); err != nil {
+		return err
+	}
+	return migrateSchema(db)
+}
+
+// openDatabase opens the database at path and prepares its schema.
+func openDatabase(path string) (*sql.DB, error) {
+	db, err := openSQLite(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := prepareSchema(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
 // createTimerTab builds the timer UI where user can start/stop and save a session.
-// The timer calculates duration (time.Duration) and earnings before saving.
-func createTimerTab(db *sql.DB) fyne.CanvasObject {
+// The timer calculates duration (time.Duration) and earnings before saving;
+// earnings are based on the duration billed under the project's rounding policy.
+// Starting and stopping are reported to notify; saving goes through the store.
+// The returned finish func stops the ticker and saves a running or stopped
+// session; it is called before the workspace is closed.
+func createTimerTab(db *sql.DB, store SessionStore, cfg *Config, notify func(event string, s Session)) (fyne.CanvasObject, func() error) {
 	var start, end time.Time
 	var duration time.Duration
 	var ticker *time.Ticker
@@ -92,31 +320,52 @@ func createTimerTab(db *sql.DB) fyne.CanvasObject {
 	elapsedLabel := widget.NewLabelWithData(elapsedData)
 	_ = elapsedData.Set("00:00:00")
 
+	// currency of the running session, chosen together with the rate
+	currentCurrency := cfg.Currency
+	currencySelect := widget.NewSelect(currencyCodes, func(code string) {
+		currentCurrency = code
+	})
+	currencySelect.SetSelected(currentCurrency)
+
 	earningsData := binding.NewString()
 	earningsLabel := widget.NewLabelWithData(earningsData)
-	_ = earningsData.Set("0.00€")
+	_ = earningsData.Set(formatMoney(0, currentCurrency))
 
 	// show current rate
 	rateDisplay := widget.NewLabel("Rate: -")
-	var currentRate float64
+	var currentRate Money
 	var rateSet bool
+	var loadRecent func()
 
 	// create entries
 	titleEntry = widget.NewEntry()
 	descEntry = widget.NewEntry()
 	hourlyRateEntry = widget.NewEntry()
+	projectEntry := widget.NewSelectEntry(getProjectNames(db))
+
+	// timerSession describes the running or stopped timer for notify
+	timerSession := func() Session {
+		s := newSession(titleEntry.Text, descEntry.Text, projectEntry.Text, start, end, localZoneName())
+		s.HourlyRate = currentRate
+		s.Currency = currentCurrency
+		return s
+	}
+
+	// container for quick restart buttons of recently tracked tasks
+	recentList := container.NewVBox()
 
 	// button to lock in the hourly rate before starting
 	setRateBtn = widget.NewButton("Set rate", func() {
-		r, err := strconv.ParseFloat(hourlyRateEntry.Text, 64)
+		r, err := parseMoney(hourlyRateEntry.Text)
 		if err != nil {
 			statusLabel.SetText("Invalid hourly rate!")
 			return
 		}
 		currentRate = r
 		rateSet = true
-		rateDisplay.SetText(fmt.Sprintf("Rate: %.2f€/h", currentRate))
+		rateDisplay.SetText("Rate: " + formatRate(currentRate, currentCurrency))
 		hourlyRateEntry.Hide()
+		currencySelect.Hide()
 		setRateBtn.Hide()
 	})
 
@@ -124,19 +373,22 @@ func createTimerTab(db *sql.DB) fyne.CanvasObject {
 	startBtn = widget.NewButton("Start timer", func() {
 		if !rateSet {
 			// try to parse rate on start if not set
-			r, err := strconv.ParseFloat(hourlyRateEntry.Text, 64)
+			r, err := parseMoney(hourlyRateEntry.Text)
 			if err != nil {
 				statusLabel.SetText("Set a valid hourly rate first!")
 				return
 			}
 			currentRate = r
 			rateSet = true
-			rateDisplay.SetText(fmt.Sprintf("Rate: %.2f€/h", currentRate))
+			rateDisplay.SetText("Rate: " + formatRate(currentRate, currentCurrency))
 			hourlyRateEntry.Hide()
+			currencySelect.Hide()
 			setRateBtn.Hide()
 		}
 
 		start = time.Now()
+		end = start
+		notify(eventTimerStarted, timerSession())
 		statusLabel.SetText("Timer running...")
 		startBtn.Hide()
 		stopBtn.Show()
@@ -165,8 +417,7 @@ func createTimerTab(db *sql.DB) fyne.CanvasObject {
 					s := int(el.Seconds()) % 60
 					_ = elapsedData.Set(fmt.Sprintf("%02d:%02d:%02d", h, m, s))
 
-					earned := math.Round((el.Hours()*currentRate)*100) / 100
-					_ = earningsData.Set(fmt.Sprintf("%.2f€", earned))
+					_ = earningsData.Set(formatMoney(earningsFor(currentRate, el), currentCurrency))
 				case <-q:
 					return
 				}
@@ -194,7 +445,10 @@ func createTimerTab(db *sql.DB) fyne.CanvasObject {
 		// show inputs to save session
 		titleEntry.Show()
 		descEntry.Show()
+		projectEntry.SetOptions(getProjectNames(db))
+		projectEntry.Show()
 		hourlyRateEntry.Show()
+		currencySelect.Show()
 		setRateBtn.Show()
 		saveBtn.Show()
 
@@ -204,16 +458,46 @@ func createTimerTab(db *sql.DB) fyne.CanvasObject {
 		se := int(duration.Seconds()) % 60
 		_ = elapsedData.Set(fmt.Sprintf("%02d:%02d:%02d", h, m, se))
 
-		finalEarned := math.Round((duration.Hours()*currentRate)*100) / 100
-		_ = earningsData.Set(fmt.Sprintf("%.2f€", finalEarned))
+		billed, err := billedDuration(db, cfg, projectEntry.Text, duration)
+		if err != nil {
+			showError("reading rounding policy", err)
+		}
+		_ = earningsData.Set(formatMoney(earningsFor(currentRate, billed), currentCurrency))
+
+		stopped := timerSession()
+		stopped.bill(billed)
+		notify(eventTimerStopped, stopped)
 	})
 
-	// save button: persist and reset timer + earnings
-	saveBtn = widget.NewButton("Save session", func() {
+	// saveTimer persists the stopped session and resets timer + earnings
+	saveTimer := func() error {
+		// if user changed rate entry before save, prefer parsed value; otherwise use currentRate
+		if hourlyRateEntry.Text != "" {
+			if r, err := parseMoney(hourlyRateEntry.Text); err == nil {
+				currentRate = r
+			}
+		}
+
+		// project may have been chosen after stopping, so round again
+		billed, err := billedDuration(db, cfg, projectEntry.Text, duration)
+		if err != nil {
+			return fmt.Errorf("reading rounding policy: %w", err)
+		}
+		earnings := earningsFor(currentRate, billed)
+
+		// save session (start/end passed as time.Time); on failure the inputs
+		// stay filled so saving can be retried
+		err = saveSession(store, titleEntry.Text, descEntry.Text, projectEntry.Text, start, end, int64(duration.Seconds()), int64(billed.Seconds()), currentRate, earnings, currentCurrency)
+		if err != nil {
+			return err
+		}
+
 		// hide inputs and show start again
 		titleEntry.Hide()
 		descEntry.Hide()
+		projectEntry.Hide()
 		hourlyRateEntry.Hide()
+		currencySelect.Hide()
 		setRateBtn.Hide()
 		saveBtn.Hide()
 		startBtn.Show()
@@ -222,42 +506,82 @@ func createTimerTab(db *sql.DB) fyne.CanvasObject {
 		rateSet = false
 		rateDisplay.SetText("Rate: -")
 
-		// if user changed rate entry before save, prefer parsed value; otherwise use currentRate
-		if hourlyRateEntry.Text != "" {
-			if r, err := strconv.ParseFloat(hourlyRateEntry.Text, 64); err == nil {
-				currentRate = r
-			}
-		}
-
-		hours := duration.Hours()
-		earnings := math.Round((hours*currentRate)*100) / 100
-
-		// save session (start/end passed as time.Time)
-		err := saveSession(db, titleEntry.Text, descEntry.Text, start, end, int64(duration.Seconds()), currentRate, earnings)
-		if err != nil {
-			statusLabel.SetText("Error saving session: " + err.Error())
-			return
-		}
-
 		// feedback and clear
-		statusLabel.SetText(fmt.Sprintf("Session '%s' saved. Duration: %s. Earnings: %.2f€", titleEntry.Text, duration.String(), earnings))
+		statusLabel.SetText(fmt.Sprintf("Session '%s' saved. Duration: %s (billed %s). Earnings: %s", titleEntry.Text, duration.String(), billed.String(), formatMoney(earnings, currentCurrency)))
 		titleEntry.SetText("")
 		descEntry.SetText("")
-		hourlyRateEntry.SetText("")
+		projectEntry.SetText("")
+		hourlyRateEntry.SetText(cfg.defaultRateText())
 
 		// reset live displays
 		_ = elapsedData.Set("00:00:00")
-		_ = earningsData.Set("0.00€")
+		_ = earningsData.Set(formatMoney(0, currentCurrency))
 		elapsedLabel.Hide()
 		earningsLabel.Hide()
 		hourlyRateEntry.Show()
+		currencySelect.Show()
 		setRateBtn.Show()
+
+		// the saved session may be a new recent task
+		loadRecent()
+		return nil
+	}
+	saveBtn = widget.NewButton("Save session", func() {
+		if err := saveTimer(); err != nil {
+			showError("saving session", err)
+		}
 	})
 
+	// loadRecent refills the quick restart buttons; a tap prefills the inputs and starts the timer
+	loadRecent = func() {
+		recentList.RemoveAll()
+		tasks, err := store.RecentTasks(maxRecentTasks)
+		if err != nil {
+			showError("loading recent tasks", err)
+			return
+		}
+		if len(tasks) == 0 {
+			return
+		}
+		recentList.Add(widget.NewLabel("Recent tasks"))
+		for _, t := range tasks {
+			task := t
+			label := task.Title
+			if task.Project != "" {
+				label = "[" + task.Project + "] " + label
+			}
+			if task.Description != "" {
+				label += " - " + task.Description
+			}
+			label += " (" + formatRate(task.HourlyRate, task.Currency) + ")"
+			recentList.Add(widget.NewButton(label, func() {
+				// ignore while a timer is running or a stopped session waits to be saved
+				if !startBtn.Visible() {
+					statusLabel.SetText("Finish the current session first")
+					return
+				}
+				titleEntry.SetText(task.Title)
+				descEntry.SetText(task.Description)
+				projectEntry.SetText(task.Project)
+				hourlyRateEntry.SetText("")
+				currentRate = task.HourlyRate
+				currencySelect.SetSelected(task.Currency)
+				rateSet = true
+				rateDisplay.SetText("Rate: " + formatRate(currentRate, currentCurrency))
+				hourlyRateEntry.Hide()
+				currencySelect.Hide()
+				setRateBtn.Hide()
+				startBtn.OnTapped()
+			}))
+		}
+	}
+
 	// initial visibility
 	titleEntry.Hide()
 	descEntry.Hide()
-	hourlyRateEntry.SetPlaceHolder("Hourly rate (€)")
+	projectEntry.Hide()
+	hourlyRateEntry.SetPlaceHolder("Hourly rate")
+	hourlyRateEntry.SetText(cfg.defaultRateText())
 	hourlyRateEntry.Show() // let user enter rate before start
 	setRateBtn.Show()
 	saveBtn.Hide()
@@ -269,22 +593,47 @@ func createTimerTab(db *sql.DB) fyne.CanvasObject {
 	titleEntry.SetPlaceHolder("Enter title...")
 	descEntry.SetPlaceHolder("Description (optional)")
 	descEntry.MultiLine = true
+	projectEntry.SetPlaceHolder("Project (optional)")
+	// choosing a project switches to its default currency
+	projectEntry.OnChanged = func(name string) {
+		if code := currencyFor(db, cfg, name); code != currentCurrency && !rateSet {
+			currencySelect.SetSelected(code)
+		}
+	}
+
+	loadRecent()
+
+	// finish stops a running timer and saves what it tracked, so that
+	// switching workspaces neither loses the session nor leaks the ticker
+	finish := func() error {
+		if startBtn.Visible() {
+			return nil // nothing running or waiting to be saved
+		}
+		if stopBtn.Visible() {
+			stopBtn.OnTapped()
+		}
+		return saveTimer()
+	}
 
-	// layout: status, live displays, controls, inputs
+	// layout: status, live displays, controls, inputs, recent tasks
 	return container.NewVBox(
 		statusLabel,
 		container.NewHBox(widget.NewLabel("Elapsed: "), elapsedLabel, widget.NewLabel("  Earned: "), earningsLabel, rateDisplay),
-		container.NewVBox(hourlyRateEntry, setRateBtn, startBtn, stopBtn),
+		container.NewVBox(container.NewBorder(nil, nil, nil, currencySelect, hourlyRateEntry), setRateBtn, startBtn, stopBtn),
 		widget.NewSeparator(),
 		titleEntry,
 		descEntry,
+		projectEntry,
 		saveBtn,
-	)
+		widget.NewSeparator(),
+		recentList,
+	), finish
 }
 
 // createSessionsTab builds the view that lists saved sessions.
 // It shows count and total earnings and supports manual refresh.
-func createSessionsTab(db *sql.DB) fyne.CanvasObject {
+// Commits made during a session in the configured repositories are listed on its card.
+func createSessionsTab(store SessionStore, cfg *Config) fyne.CanvasObject {
 	// vertical container to hold session cards
 	sessionsList := container.NewVBox()
 
@@ -294,38 +643,104 @@ func createSessionsTab(db *sql.DB) fyne.CanvasObject {
 
 	// summary labels
 	countLabel := widget.NewLabel("Count: 0")
-	totalLabel := widget.NewLabel("Total earnings: 0.00€")
+	totalLabel := widget.NewLabel("Total earnings: " + formatTotals(nil))
 	var refreshBtn *widget.Button
 
-	// loader function to refill sessionsList from DB
+	// optional range for the list and totals
+	rangeLabel := widget.NewLabel("All sessions")
+	rangeStart := newTimeEntry("Range start (empty: all sessions)")
+	rangeEnd := newTimeEntry("Range end (empty: now)")
+	rangeModeSelect := newRangeModeSelect()
+
+	// commits read per span of sessions, and the number of the latest load so
+	// that commits arriving for an older list are not shown; both are only
+	// used on the UI goroutine
+	commitCache := map[commitRange]commitResult{}
+	loads := 0
+
+	// loader function to refill sessionsList from the store
 	loadSessions := func() {
+		loads++
+		load := loads
+		from, to, ranged, err := parseOptionalRange(rangeStart.Text, rangeEnd.Text, time.Now(), time.Local)
+		if err != nil {
+			rangeLabel.SetText("Invalid time range: " + err.Error())
+			return
+		}
+		mode := RangeMode(rangeModeSelect.Selected)
+		sessions, err := store.List(SessionFilter{From: from, To: to, Mode: mode})
+		if err != nil {
+			showError("reading sessions", err)
+			return
+		}
+		if ranged {
+			rangeLabel.SetText(fmt.Sprintf("Sessions %s from %s to %s", mode, from.Format(displayLayout), to.Format(displayLayout)))
+		} else {
+			rangeLabel.SetText("All sessions")
+		}
 		sessionsList.RemoveAll()
-		sessions := getAllSessions(db)
 
-		var total float64
+		// the commits of each card are added to its box once git is done
+		commitBoxes := map[int]*fyne.Container{}
+
+		// totals are kept per currency and never summed across currencies
+		totals := map[string]Money{}
 		for _, s := range sessions {
-			total += s.Earnings
+			totals[s.Currency] += s.Earnings
 
 			// create small labels for each session row
 			timeLabel := widget.NewLabel("Time: " + s.StartTime + " - " + s.EndTime)
-			durationLabel := widget.NewLabel("Duration: " + (time.Duration(s.Difference) * time.Second).String())
-			earningsLabel := widget.NewLabel("Earnings: " + strconv.FormatFloat(s.Earnings, 'f', 2, 64) + "€")
+			durationLabel := widget.NewLabel("Duration: " + (time.Duration(s.Difference) * time.Second).String() + " (billed " + (time.Duration(s.BilledDifference) * time.Second).String() + ")")
+			earningsLabel := widget.NewLabel("Earnings: " + formatMoney(s.Earnings, s.Currency) + " (" + formatRate(s.HourlyRate, s.Currency) + ")")
 
 			// pack into a card for better visual separation
-			card := widget.NewCard(s.Title, "", container.NewVBox(
+			details := container.NewVBox(
 				widget.NewLabel("ID: "+strconv.Itoa(s.ID)),
+				widget.NewLabel("Project: "+s.Project),
 				timeLabel,
 				durationLabel,
 				earningsLabel,
 				widget.NewLabel("Description: "+s.Description),
-				widget.NewLabel("Created by: "+s.CreatedBy),
-				divider,
-			))
+				widget.NewLabel("Created by: "+s.person()),
+			)
+			commitBoxes[s.ID] = container.NewVBox()
+			details.Add(commitBoxes[s.ID])
+			details.Add(divider)
+			card := widget.NewCard(s.Title, "", details)
 			sessionsList.Add(card)
 		}
 		// update summary labels
 		countLabel.SetText("Count: " + strconv.Itoa(len(sessions)))
-		totalLabel.SetText("Total earnings: " + strconv.FormatFloat(total, 'f', 2, 64) + "€")
+		totalLabel.SetText("Total earnings: " + formatTotals(totals))
+
+		// commits of the whole list are read at once; unreadable repositories are only mentioned
+		if len(cfg.GitRepos) == 0 || len(sessions) == 0 {
+			return
+		}
+		rangeText := rangeLabel.Text
+		showCommits := func(r commitResult) {
+			if r.err != nil {
+				rangeLabel.SetText(rangeText + " (git: " + r.err.Error() + ")")
+			} else {
+				rangeLabel.SetText(rangeText)
+			}
+			for id, c := range attachCommits(sessions, r.commits) {
+				commitBoxes[id].Add(widget.NewLabel("Commits:\n" + commitLines(c, maxCardCommits)))
+			}
+		}
+		first, last := sessionsSpan(sessions)
+		key := newCommitRange(cfg, first, last)
+		if r, ok := commitCache[key]; ok {
+			showCommits(r)
+			return
+		}
+		rangeLabel.SetText(rangeText + " (reading commits...)")
+		readCommitsAsync(cfg, first, last, func(r commitResult) {
+			commitCache[key] = r
+			if load == loads {
+				showCommits(r)
+			}
+		})
 	}
 
 	// refresh button to reload data
@@ -341,7 +756,11 @@ func createSessionsTab(db *sql.DB) fyne.CanvasObject {
 
 	// layout: top-left controls, bottom summary, center scroll area
 	return container.NewBorder(
-		container.NewVBox(refreshBtn, widget.NewLabel("All sessions")),
+		container.NewVBox(
+			container.NewGridWithColumns(2, rangeStart, rangeEnd),
+			container.NewHBox(widget.NewLabel("Sessions on the range edges:"), rangeModeSelect, refreshBtn),
+			rangeLabel,
+		),
 		container.NewVBox(countLabel, totalLabel),
 		nil,
 		nil,
@@ -350,7 +769,7 @@ func createSessionsTab(db *sql.DB) fyne.CanvasObject {
 }
 
 // createAddSessionTab provides UI to add a session by manually entering start and end times.
-func createAddSessionTab(db *sql.DB) fyne.CanvasObject {
+func createAddSessionTab(db *sql.DB, store SessionStore, cfg *Config) fyne.CanvasObject {
 	var addBtn, saveBtn *widget.Button
 	var titleEntry, descEntry, startEntry, endEntry, hourlyRateEntry *widget.Entry
 	statusLabel := widget.NewLabel("Add session")
@@ -360,16 +779,28 @@ func createAddSessionTab(db *sql.DB) fyne.CanvasObject {
 	// create entries
 	titleEntry = widget.NewEntry()
 	descEntry = widget.NewEntry()
-	startEntry = widget.NewEntry()
-	endEntry = widget.NewEntry()
+	startEntry = newTimeEntry("Start")
+	endEntry = newTimeEntry("End or duration like 1h30m")
 	hourlyRateEntry = widget.NewEntry()
+	projectEntry := widget.NewSelectEntry(getProjectNames(db))
+	currencySelect := widget.NewSelect(currencyCodes, nil)
+	currencySelect.SetSelected(cfg.Currency)
+	projectEntry.OnChanged = func(name string) {
+		currencySelect.SetSelected(currencyFor(db, cfg, name))
+	}
 
 	// new session button: reveal inputs
 	addBtn = widget.NewButton("New session", func() {
 		titleEntry.Show()
 		descEntry.Show()
+		projectEntry.SetOptions(getProjectNames(db))
+		projectEntry.Show()
+		currencySelect.Show()
 		startEntry.Show()
 		endEntry.Show()
+		if hourlyRateEntry.Text == "" {
+			hourlyRateEntry.SetText(cfg.defaultRateText())
+		}
 		hourlyRateEntry.Show()
 		saveBtn.Show()
 		addBtn.Hide()
@@ -378,47 +809,50 @@ func createAddSessionTab(db *sql.DB) fyne.CanvasObject {
 
 	// save handler: parse times and rate, compute earnings and persist
 	saveBtn = widget.NewButton("Save", func() {
-		// hide inputs and show add button again
-		titleEntry.Hide()
-		descEntry.Hide()
-		startEntry.Hide()
-		endEntry.Hide()
-		hourlyRateEntry.Hide()
-		saveBtn.Hide()
-		addBtn.Show()
-
 		var err error
-		start, err = time.Parse("2006-01-02 15:04:05", startEntry.Text)
-		if err != nil {
-			statusLabel.SetText("Invalid start time format!")
-			return
-		}
-		end, err = time.Parse("2006-01-02 15:04:05", endEntry.Text)
+		start, end, err = parseTimeRangeInput(startEntry.Text, endEntry.Text, time.Now(), time.Local)
 		if err != nil {
-			statusLabel.SetText("Invalid end time format!")
+			statusLabel.SetText("Invalid time: " + err.Error())
 			return
 		}
 		duration = end.Sub(start)
 
-		hourlyRate, err := strconv.ParseFloat(hourlyRateEntry.Text, 64)
+		hourlyRate, err := parseMoney(hourlyRateEntry.Text)
 		if err != nil {
 			statusLabel.SetText("Invalid hourly rate!")
 			return
 		}
-		hours := duration.Hours()
-		earnings := math.Round((hours*hourlyRate)*100) / 100
+		billed, err := billedDuration(db, cfg, projectEntry.Text, duration)
+		if err != nil {
+			showError("reading rounding policy", err)
+			return
+		}
+		earnings := earningsFor(hourlyRate, billed)
 
-		// save to DB (saveSession returns an error we surface to user)
-		err = saveSession(db, titleEntry.Text, descEntry.Text, start, end, int64(duration.Seconds()), hourlyRate, earnings)
+		// save to DB (saveSession returns an error we surface to user);
+		// the inputs stay until the session is saved
+		err = saveSession(store, titleEntry.Text, descEntry.Text, projectEntry.Text, start, end, int64(duration.Seconds()), int64(billed.Seconds()), hourlyRate, earnings, currencySelect.Selected)
 		if err != nil {
-			statusLabel.SetText("Error saving session: " + err.Error())
+			showError("saving session", err)
 			return
 		}
 
+		// hide inputs and show add button again
+		titleEntry.Hide()
+		descEntry.Hide()
+		projectEntry.Hide()
+		startEntry.Hide()
+		endEntry.Hide()
+		hourlyRateEntry.Hide()
+		currencySelect.Hide()
+		saveBtn.Hide()
+		addBtn.Show()
+
 		// success message and clear fields
-		statusLabel.SetText(fmt.Sprintf("Saved '%s'. Duration: %s. Earnings: %.2f€", titleEntry.Text, duration.String(), earnings))
+		statusLabel.SetText(fmt.Sprintf("Saved '%s'. Duration: %s (billed %s). Earnings: %s", titleEntry.Text, duration.String(), billed.String(), formatMoney(earnings, currencySelect.Selected)))
 		titleEntry.SetText("")
 		descEntry.SetText("")
+		projectEntry.SetText("")
 		startEntry.SetText("")
 		endEntry.SetText("")
 		hourlyRateEntry.SetText("")
@@ -428,43 +862,61 @@ func createAddSessionTab(db *sql.DB) fyne.CanvasObject {
 	saveBtn.Hide()
 	titleEntry.Hide()
 	descEntry.Hide()
+	projectEntry.Hide()
 	startEntry.Hide()
 	endEntry.Hide()
 	hourlyRateEntry.Hide()
+	currencySelect.Hide()
 
 	// placeholders
 	titleEntry.SetPlaceHolder("Title...")
 	descEntry.SetPlaceHolder("Description (optional)")
 	descEntry.MultiLine = true
-	startEntry.SetPlaceHolder("Start (YYYY-MM-DD HH:MM:SS)")
-	endEntry.SetPlaceHolder("End (YYYY-MM-DD HH:MM:SS)")
-	hourlyRateEntry.SetPlaceHolder("Hourly rate (€)")
+	projectEntry.SetPlaceHolder("Project (optional)")
+	hourlyRateEntry.SetPlaceHolder("Hourly rate")
 
-	return container.NewVBox(
+	// sessions suggested from git commits are saved at the default rate
+	suggestions := newCommitSuggestions(cfg, store, func(sg Suggestion) error {
+		duration := sg.End.Sub(sg.Start)
+		billed, err := billedDuration(db, cfg, "", duration)
+		if err != nil {
+			return err
+		}
+		return saveSession(store, sg.Title, sg.Description, "", sg.Start, sg.End, int64(duration.Seconds()), int64(billed.Seconds()), cfg.DefaultRate, earningsFor(cfg.DefaultRate, billed), cfg.Currency)
+	})
+
+	return container.NewVScroll(container.NewVBox(
 		statusLabel,
 		addBtn,
 		titleEntry,
 		descEntry,
+		projectEntry,
 		startEntry,
 		endEntry,
-		hourlyRateEntry,
+		container.NewBorder(nil, nil, nil, currencySelect, hourlyRateEntry),
 		saveBtn,
-	)
+		suggestions,
+	))
 }
 
 // createEditSessionTab lets the user load a session by ID and edit individual fields.
-// It reads the current values from the database and updates only selected columns.
-func createEditSessionTab(db *sql.DB) fyne.CanvasObject {
+// Each change reads the current session from the store and writes it back.
+// Changes to times, project or rate recompute the billed duration and earnings.
+func createEditSessionTab(db *sql.DB, store SessionStore, cfg *Config) fyne.CanvasObject {
 	var idEntry *widget.Entry
 	var loadBtn *widget.Button
-	var editTitleBtn, editDescBtn, editStartBtn, editEndBtn, editHourlyRateBtn *widget.Button
-	var confirmTitleBtn, confirmDescBtn, confirmStartBtn, confirmEndBtn, confirmHourlyRateBtn *widget.Button
+	var editTitleBtn, editDescBtn, editStartBtn, editEndBtn, editHourlyRateBtn, editProjectBtn, editCurrencyBtn *widget.Button
+	var confirmTitleBtn, confirmDescBtn, confirmStartBtn, confirmEndBtn, confirmHourlyRateBtn, confirmProjectBtn, confirmCurrencyBtn *widget.Button
 	var cancelBtn *widget.Button
 	var newTitle, newDesc, newStart, newEnd, newHourlyRate *widget.Entry
+	var newProject *widget.SelectEntry
+	var newCurrency *widget.Select
 
 	// output label displays messages or loaded session summary
 	outputLabel := widget.NewLabel("")
-	var getQuery, updateQuery string
+
+	// id of the loaded session; the id entry is cleared once a session is loaded
+	var sessionID int
 
 	// input for session id
 	idEntry = widget.NewEntry()
@@ -473,9 +925,31 @@ func createEditSessionTab(db *sql.DB) fyne.CanvasObject {
 	// entries for new values
 	newTitle = widget.NewEntry()
 	newDesc = widget.NewEntry()
-	newStart = widget.NewEntry()
-	newEnd = widget.NewEntry()
+	newStart = newTimeEntry("New start, session time zone")
+	newEnd = newTimeEntry("New end or duration, session time zone")
 	newHourlyRate = widget.NewEntry()
+	newProject = widget.NewSelectEntry(getProjectNames(db))
+	newCurrency = widget.NewSelect(currencyCodes, nil)
+
+	// rebill recomputes billed duration and earnings under the project's rounding
+	rebill := func(s *Session) error {
+		billed, err := billedDuration(db, cfg, s.Project, s.Duration())
+		if err != nil {
+			return err
+		}
+		s.bill(billed)
+		return nil
+	}
+
+	// load reads the loaded session, reporting errors in the output label
+	load := func() (Session, bool) {
+		s, err := store.Get(sessionID)
+		if err != nil {
+			showError("reading session", err)
+			return s, false
+		}
+		return s, true
+	}
 
 	// load button: fetch session summary and show edit options
 	loadBtn = widget.NewButton("Load session", func() {
@@ -484,11 +958,13 @@ func createEditSessionTab(db *sql.DB) fyne.CanvasObject {
 			outputLabel.SetText("Invalid ID!")
 			return
 		}
-		summary, found := getSessionSummaryByID(db, idVal)
+		summary, found := loadSessionSummary(store, idVal)
 		if !found {
 			outputLabel.SetText(summary)
 			return
 		}
+		sessionID = idVal
+
 		// show edit options
 		idEntry.Hide()
 		idEntry.SetText("")
@@ -498,11 +974,15 @@ func createEditSessionTab(db *sql.DB) fyne.CanvasObject {
 		editStartBtn.Show()
 		editEndBtn.Show()
 		editHourlyRateBtn.Show()
+		editProjectBtn.Show()
+		editCurrencyBtn.Show()
 		outputLabel.SetText("Choose field to edit: " + summary)
 	})
 
 	// buttons to choose which field to edit (show corresponding entry)
 	editTitleBtn = widget.NewButton("Edit title", func() {
+		editCurrencyBtn.Hide()
+		editProjectBtn.Hide()
 		editTitleBtn.Hide()
 		editDescBtn.Hide()
 		editStartBtn.Hide()
@@ -515,6 +995,8 @@ func createEditSessionTab(db *sql.DB) fyne.CanvasObject {
 	})
 
 	editDescBtn = widget.NewButton("Edit description", func() {
+		editCurrencyBtn.Hide()
+		editProjectBtn.Hide()
 		editDescBtn.Hide()
 		editTitleBtn.Hide()
 		editStartBtn.Hide()
@@ -527,47 +1009,82 @@ func createEditSessionTab(db *sql.DB) fyne.CanvasObject {
 	})
 
 	editStartBtn = widget.NewButton("Edit start time", func() {
+		editCurrencyBtn.Hide()
+		editProjectBtn.Hide()
 		editStartBtn.Hide()
 		editTitleBtn.Hide()
 		editDescBtn.Hide()
 		editEndBtn.Hide()
 		editHourlyRateBtn.Hide()
 		newStart.Show()
-		newStart.SetPlaceHolder("New start (YYYY-MM-DD HH:MM:SS)")
 		confirmStartBtn.Show()
 		cancelBtn.Show()
 	})
 
 	editEndBtn = widget.NewButton("Edit end time", func() {
+		editCurrencyBtn.Hide()
+		editProjectBtn.Hide()
 		editEndBtn.Hide()
 		editTitleBtn.Hide()
 		editDescBtn.Hide()
 		editStartBtn.Hide()
 		editHourlyRateBtn.Hide()
 		newEnd.Show()
-		newEnd.SetPlaceHolder("New end (YYYY-MM-DD HH:MM:SS)")
 		confirmEndBtn.Show()
 		cancelBtn.Show()
 	})
 
 	editHourlyRateBtn = widget.NewButton("Edit hourly rate", func() {
+		editCurrencyBtn.Hide()
+		editProjectBtn.Hide()
 		editHourlyRateBtn.Hide()
 		editTitleBtn.Hide()
 		editDescBtn.Hide()
 		editStartBtn.Hide()
 		editEndBtn.Hide()
 		newHourlyRate.Show()
-		newHourlyRate.SetPlaceHolder("New hourly rate (€)")
+		newHourlyRate.SetPlaceHolder("New hourly rate")
 		confirmHourlyRateBtn.Show()
 		cancelBtn.Show()
 	})
 
+	editProjectBtn = widget.NewButton("Edit project", func() {
+		editCurrencyBtn.Hide()
+		editProjectBtn.Hide()
+		editTitleBtn.Hide()
+		editDescBtn.Hide()
+		editStartBtn.Hide()
+		editEndBtn.Hide()
+		editHourlyRateBtn.Hide()
+		newProject.SetOptions(getProjectNames(db))
+		newProject.Show()
+		newProject.SetPlaceHolder("New project (empty for none)...")
+		confirmProjectBtn.Show()
+		cancelBtn.Show()
+	})
+
+	editCurrencyBtn = widget.NewButton("Edit currency", func() {
+		editCurrencyBtn.Hide()
+		editTitleBtn.Hide()
+		editDescBtn.Hide()
+		editStartBtn.Hide()
+		editEndBtn.Hide()
+		editHourlyRateBtn.Hide()
+		editProjectBtn.Hide()
+		newCurrency.Show()
+		confirmCurrencyBtn.Show()
+		cancelBtn.Show()
+	})
+
 	// confirm buttons perform the updates and recompute dependent fields (difference, earnings)
 	confirmTitleBtn = widget.NewButton("Save title", func() {
-		updateQuery = "UPDATE work_sessions SET title = ? WHERE id = ?"
-		_, err := db.Exec(updateQuery, newTitle.Text, idEntry.Text)
-		if err != nil {
-			outputLabel.SetText("Error saving title: " + err.Error())
+		s, ok := load()
+		if !ok {
+			return
+		}
+		s.Title = newTitle.Text
+		if err := store.Update(s); err != nil {
+			showError("saving title", err)
 			return
 		}
 		outputLabel.SetText("Title updated")
@@ -579,10 +1096,13 @@ func createEditSessionTab(db *sql.DB) fyne.CanvasObject {
 	})
 
 	confirmDescBtn = widget.NewButton("Save description", func() {
-		updateQuery = "UPDATE work_sessions SET description = ? WHERE id = ?"
-		_, err := db.Exec(updateQuery, newDesc.Text, idEntry.Text)
-		if err != nil {
-			outputLabel.SetText("Error saving description: " + err.Error())
+		s, ok := load()
+		if !ok {
+			return
+		}
+		s.Description = newDesc.Text
+		if err := store.Update(s); err != nil {
+			showError("saving description", err)
 			return
 		}
 		outputLabel.SetText("Description updated")
@@ -593,36 +1113,31 @@ func createEditSessionTab(db *sql.DB) fyne.CanvasObject {
 		confirmDescBtn.Hide()
 	})
 
-	// confirm start: need end time and hourly rate from DB to recompute earnings
+	// confirm start: end time, project and rate of the session give the new earnings
+	// the new time is read in the zone the session was recorded in
 	confirmStartBtn = widget.NewButton("Save start time", func() {
-		getQuery = "SELECT end_time, hourly_rate FROM work_sessions WHERE id = ?"
-		row := db.QueryRow(getQuery, idEntry.Text)
-		var endStr string
-		var hourlyRate float64
-		err := row.Scan(&endStr, &hourlyRate)
-		if err != nil {
-			outputLabel.SetText("Error reading session: " + err.Error())
+		s, ok := load()
+		if !ok {
 			return
 		}
-
-		endTime, err := time.Parse("2006-01-02 15:04", endStr)
+		newStartTime, err := parseTimeInput(newStart.Text, time.Now(), zoneLocation(s.TimeZone))
 		if err != nil {
-			outputLabel.SetText("Invalid end time in DB!")
+			outputLabel.SetText("Invalid start time format!")
 			return
 		}
-		newStartTime, err := time.Parse("2006-01-02 15:04", newStart.Text)
-		if err != nil {
-			outputLabel.SetText("Invalid start time format!")
+		if !s.End().After(newStartTime) {
+			outputLabel.SetText("Start time must be before the end time!")
 			return
 		}
 
 		// recompute duration and earnings
-		duration := endTime.Sub(newStartTime)
-		earnings := math.Round((duration.Hours()*hourlyRate)*100) / 100
-		updateQuery = "UPDATE work_sessions SET start_time = ?, difference = ?, earnings = ? WHERE id = ?"
-		_, err = db.Exec(updateQuery, newStart.Text, int64(duration.Seconds()), earnings, idEntry.Text)
-		if err != nil {
-			outputLabel.SetText("Error updating start time: " + err.Error())
+		s.setTimes(newStartTime, s.End())
+		if err := rebill(&s); err != nil {
+			showError("reading rounding policy", err)
+			return
+		}
+		if err := store.Update(s); err != nil {
+			showError("updating start time", err)
 			return
 		}
 		outputLabel.SetText("Start time updated")
@@ -633,35 +1148,29 @@ func createEditSessionTab(db *sql.DB) fyne.CanvasObject {
 		confirmStartBtn.Hide()
 	})
 
-	// confirm end: need start time and hourly rate from DB to recompute earnings
+	// confirm end: start time, project and rate of the session give the new earnings
 	confirmEndBtn = widget.NewButton("Save end time", func() {
-		getQuery = "SELECT start_time, hourly_rate FROM work_sessions WHERE id = ?"
-		row := db.QueryRow(getQuery, idEntry.Text)
-		var startStr string
-		var hourlyRate float64
-		err := row.Scan(&startStr, &hourlyRate)
-		if err != nil {
-			outputLabel.SetText("Error reading session: " + err.Error())
+		s, ok := load()
+		if !ok {
 			return
 		}
-
-		startTime, err := time.Parse("2006-01-02 15:04", startStr)
+		newEndTime, err := parseEndInput(newEnd.Text, s.Start(), time.Now(), zoneLocation(s.TimeZone))
 		if err != nil {
-			outputLabel.SetText("Invalid start time in DB!")
+			outputLabel.SetText("Invalid end time format!")
 			return
 		}
-		newEndTime, err := time.Parse("2006-01-02 15:04", newEnd.Text)
-		if err != nil {
-			outputLabel.SetText("Invalid end time format!")
+		if !newEndTime.After(s.Start()) {
+			outputLabel.SetText("End time must be after the start time!")
 			return
 		}
 
-		duration := newEndTime.Sub(startTime)
-		earnings := math.Round((duration.Hours()*hourlyRate)*100) / 100
-		updateQuery = "UPDATE work_sessions SET end_time = ?, difference = ?, earnings = ? WHERE id = ?"
-		_, err = db.Exec(updateQuery, newEnd.Text, int64(duration.Seconds()), earnings, idEntry.Text)
-		if err != nil {
-			outputLabel.SetText("Error updating end time: " + err.Error())
+		s.setTimes(s.Start(), newEndTime)
+		if err := rebill(&s); err != nil {
+			showError("reading rounding policy", err)
+			return
+		}
+		if err := store.Update(s); err != nil {
+			showError("updating end time", err)
 			return
 		}
 		outputLabel.SetText("End time updated")
@@ -672,28 +1181,21 @@ func createEditSessionTab(db *sql.DB) fyne.CanvasObject {
 		confirmEndBtn.Hide()
 	})
 
-	// confirm rate: read stored difference (seconds), compute earnings with new rate
+	// confirm rate: the stored billed duration gives the earnings at the new rate
 	confirmHourlyRateBtn = widget.NewButton("Save hourly rate", func() {
-		getQuery = "SELECT difference FROM work_sessions WHERE id = ?"
-		row := db.QueryRow(getQuery, idEntry.Text)
-		var diffSeconds int64
-		err := row.Scan(&diffSeconds)
-		if err != nil {
-			outputLabel.SetText("Error reading difference: " + err.Error())
+		s, ok := load()
+		if !ok {
 			return
 		}
-		duration := time.Duration(diffSeconds) * time.Second
-		hours := duration.Hours()
-		newRateVal, err := strconv.ParseFloat(newHourlyRate.Text, 64)
+		newRateVal, err := parseMoney(newHourlyRate.Text)
 		if err != nil {
 			outputLabel.SetText("Invalid hourly rate!")
 			return
 		}
-		earnings := math.Round((hours*newRateVal)*100) / 100
-		updateQuery = "UPDATE work_sessions SET hourly_rate = ?, earnings = ? WHERE id = ?"
-		_, err = db.Exec(updateQuery, newRateVal, earnings, idEntry.Text)
-		if err != nil {
-			outputLabel.SetText("Error updating hourly rate: " + err.Error())
+		s.HourlyRate = newRateVal
+		s.bill(s.Billed())
+		if err := store.Update(s); err != nil {
+			showError("updating hourly rate", err)
 			return
 		}
 		outputLabel.SetText("Hourly rate updated")
@@ -704,6 +1206,52 @@ func createEditSessionTab(db *sql.DB) fyne.CanvasObject {
 		confirmHourlyRateBtn.Hide()
 	})
 
+	// confirm project: the new project's rounding policy applies to the stored raw duration
+	confirmProjectBtn = widget.NewButton("Save project", func() {
+		s, ok := load()
+		if !ok {
+			return
+		}
+		s.Project = strings.TrimSpace(newProject.Text)
+		if err := rebill(&s); err != nil {
+			showError("reading rounding policy", err)
+			return
+		}
+		if err := store.Update(s); err != nil {
+			showError("updating project", err)
+			return
+		}
+		outputLabel.SetText("Project updated")
+		newProject.SetText("")
+		newProject.Hide()
+		idEntry.Show()
+		loadBtn.Show()
+		confirmProjectBtn.Hide()
+	})
+
+	// confirm currency: amounts stay the same, only their currency changes
+	confirmCurrencyBtn = widget.NewButton("Save currency", func() {
+		if newCurrency.Selected == "" {
+			outputLabel.SetText("Choose a currency!")
+			return
+		}
+		s, ok := load()
+		if !ok {
+			return
+		}
+		s.Currency = newCurrency.Selected
+		if err := store.Update(s); err != nil {
+			showError("updating currency", err)
+			return
+		}
+		outputLabel.SetText("Currency updated")
+		newCurrency.ClearSelected()
+		newCurrency.Hide()
+		idEntry.Show()
+		loadBtn.Show()
+		confirmCurrencyBtn.Hide()
+	})
+
 	cancelBtn = widget.NewButton("Cancel", func() {
 		// hide all edit widgets and show id entry + load button
 		newTitle.Hide()
@@ -711,16 +1259,22 @@ func createEditSessionTab(db *sql.DB) fyne.CanvasObject {
 		newStart.Hide()
 		newEnd.Hide()
 		newHourlyRate.Hide()
+		newProject.Hide()
+		newCurrency.Hide()
 		newTitle.SetText("")
 		newDesc.SetText("")
 		newStart.SetText("")
 		newEnd.SetText("")
 		newHourlyRate.SetText("")
+		newProject.SetText("")
+		newCurrency.ClearSelected()
 		confirmTitleBtn.Hide()
 		confirmDescBtn.Hide()
 		confirmStartBtn.Hide()
 		confirmEndBtn.Hide()
 		confirmHourlyRateBtn.Hide()
+		confirmProjectBtn.Hide()
+		confirmCurrencyBtn.Hide()
 		cancelBtn.Hide()
 		idEntry.Show()
 		loadBtn.Show()
@@ -733,16 +1287,22 @@ func createEditSessionTab(db *sql.DB) fyne.CanvasObject {
 	editStartBtn.Hide()
 	editEndBtn.Hide()
 	editHourlyRateBtn.Hide()
+	editProjectBtn.Hide()
+	editCurrencyBtn.Hide()
 	newTitle.Hide()
 	newDesc.Hide()
 	newStart.Hide()
 	newEnd.Hide()
 	newHourlyRate.Hide()
+	newProject.Hide()
+	newCurrency.Hide()
 	confirmTitleBtn.Hide()
 	confirmDescBtn.Hide()
 	confirmStartBtn.Hide()
 	confirmEndBtn.Hide()
 	confirmHourlyRateBtn.Hide()
+	confirmProjectBtn.Hide()
+	confirmCurrencyBtn.Hide()
 	cancelBtn.Hide()
 
 	// assemble edit tab layout
@@ -756,26 +1316,34 @@ func createEditSessionTab(db *sql.DB) fyne.CanvasObject {
 		editStartBtn,
 		editEndBtn,
 		editHourlyRateBtn,
+		editProjectBtn,
+		editCurrencyBtn,
 		newTitle,
 		newDesc,
 		newStart,
 		newEnd,
 		newHourlyRate,
+		newProject,
+		newCurrency,
 		confirmTitleBtn,
 		confirmDescBtn,
 		confirmStartBtn,
 		confirmEndBtn,
 		confirmHourlyRateBtn,
+		confirmProjectBtn,
+		confirmCurrencyBtn,
 		cancelBtn,
 	)
 }
 
 // createDeleteSessionTab allows deleting a session by ID after confirmation.
-func createDeleteSessionTab(db *sql.DB) fyne.CanvasObject {
+func createDeleteSessionTab(store SessionStore) fyne.CanvasObject {
 	var idEntry *widget.Entry
 	var loadBtn, confirmBtn *widget.Button
 	outputLabel := widget.NewLabel("")
-	query := "DELETE FROM work_sessions WHERE id = ?"
+
+	// id of the loaded session
+	var sessionID int
 
 	idEntry = widget.NewEntry()
 	idEntry.SetPlaceHolder("Enter session ID...")
@@ -787,11 +1355,12 @@ func createDeleteSessionTab(db *sql.DB) fyne.CanvasObject {
 			outputLabel.SetText("Invalid ID!")
 			return
 		}
-		summary, found := getSessionSummaryByID(db, idVal)
+		summary, found := loadSessionSummary(store, idVal)
 		if !found {
 			outputLabel.SetText(summary)
 			return
 		}
+		sessionID = idVal
 		outputLabel.SetText("Do you really want to delete this session? " + summary)
 		loadBtn.Hide()
 		confirmBtn.Show()
@@ -799,9 +1368,8 @@ func createDeleteSessionTab(db *sql.DB) fyne.CanvasObject {
 
 	// execute deletion when confirmed
 	confirmBtn = widget.NewButton("Delete session", func() {
-		_, err := db.Exec(query, idEntry.Text)
-		if err != nil {
-			outputLabel.SetText("Error deleting session: " + err.Error())
+		if err := store.Delete(sessionID); err != nil {
+			showError("deleting session", err)
 			return
 		}
 		outputLabel.SetText("Session deleted")
@@ -822,367 +1390,361 @@ func createDeleteSessionTab(db *sql.DB) fyne.CanvasObject {
 	)
 }
 
-// getAllSessions reads all sessions from the DB and returns them as []Session.
-func getAllSessions(db *sql.DB) []Session {
-	query := "SELECT id, uuid, title, description, start_time, end_time, start_unix, end_unix, difference, hourly_rate, earnings, created_by FROM work_sessions ORDER BY end_time DESC"
-	rows, err := db.Query(query)
-	if err != nil {
-		panic(err)
-	}
-	defer rows.Close()
-
-	var sessions []Session
-	for rows.Next() {
-		var s Session
-		err := rows.Scan(&s.ID, &s.uuid, &s.Title, &s.Description, &s.StartTime, &s.EndTime, &s.endUnix, &s.startUnix, &s.Difference, &s.HourlyRate, &s.Earnings, &s.CreatedBy)
-		if err != nil {
-			panic(err)
-		}
-		sessions = append(sessions, s)
-	}
-	return sessions
+// sessionSummary returns a one-line description of s for the edit and delete tabs.
+func sessionSummary(s Session) string {
+	return fmt.Sprintf("ID: %d | UUID: %s | Title: %s | Description: %s | Project: %s | %s - %s | Duration: %s | Billed: %s | Rate: %s | Earnings: %s | Created by: %s",
+		s.ID, s.uuid, s.Title, s.Description, s.Project, s.StartTime, s.EndTime, s.Duration().String(), s.Billed().String(), formatRate(s.HourlyRate, s.Currency), formatMoney(s.Earnings, s.Currency), s.CreatedBy)
 }
 
-// getSessionSummaryByID returns a printable summary and a boolean indicating if found.
-func getSessionSummaryByID(db *sql.DB, id int) (string, bool) {
-	query := "SELECT id, uuid, title, description, start_time, end_time, start_unix, end_unix, difference, hourly_rate, earnings, created_by FROM work_sessions WHERE id = ?"
-	row := db.QueryRow(query, id)
-
-	var (
-		sID         int
-		sessionUUID string
-		title       string
-		description string
-		startTime   string
-		endTime     string
-		startUnix   int64
-		endUnix     int64
-		diffSeconds int64
-		hourlyRate  float64
-		earnings    float64
-		createdBy   string
-	)
-
-	err := row.Scan(&sID, &sessionUUID, &title, &description, &startTime, &endTime, &startUnix, &endUnix, &diffSeconds, &hourlyRate, &earnings, &createdBy)
+// loadSessionSummary reads a session for the edit and delete tabs. The message
+// is the summary if the session was found, otherwise what went wrong.
+func loadSessionSummary(store SessionStore, id int) (string, bool) {
+	s, err := store.Get(id)
+	if errors.Is(err, errSessionNotFound) {
+		return fmt.Sprintf("No session with ID %d found.", id), false
+	}
 	if err != nil {
-		if err == sql.ErrNoRows {
-			msg := fmt.Sprintf("No session with ID %d found.", id)
-			return msg, false
-		}
-		panic(err)
+		return "Error reading session: " + err.Error(), false
 	}
-
-	summary := fmt.Sprintf("ID: %d | UUID: %s | Title: %s | Description: %s | %s - %s | Duration: %s | Rate: %.2f€/h | Earnings: %.2f€ | Created by: %s",
-		sID, sessionUUID, title, description, startTime, endTime, (time.Duration(diffSeconds) * time.Second).String(), hourlyRate, earnings, createdBy)
-	return summary, true
+	return sessionSummary(s), true
 }
 
 // createTable ensures the database schema exists.
-func createTable(db *sql.DB) {
+func createTable(db *sql.DB) error {
 	query := `
     CREATE TABLE IF NOT EXISTS work_sessions (
         id INTEGER PRIMARY KEY AUTOINCREMENT,
         uuid TEXT UNIQUE NOT NULL,
         title TEXT NOT NULL,
         description TEXT,
+        project TEXT,
         start_time TEXT NOT NULL,
         end_time TEXT,
 		start_unix INTEGER,
 		end_unix INTEGER,
+		timezone TEXT,
         difference INTEGER,
-        hourly_rate REAL,
-        earnings REAL,
-        created_by TEXT NOT NULL
+        billed_difference INTEGER,
+        hourly_rate_cents INTEGER,
+        earnings_cents INTEGER,
+        currency TEXT,
+        created_by TEXT NOT NULL,
+        user_name TEXT NOT NULL DEFAULT '',
+        user_email TEXT NOT NULL DEFAULT ''
     );`
 
 	_, err := db.Exec(query)
-	if err != nil {
-		panic(err)
+	return err
+}
+
+// schemaColumn is a column added after the first release. backfill runs once when the column is added.
+type schemaColumn struct {
+	name       string
+	definition string
+	backfill   string
+}
+
+// migrateSchema adds columns introduced after the first release to existing databases.
+func migrateSchema(db *sql.DB) error {
+	// columns in the order they were introduced
+	sessionColumns := []schemaColumn{
+		{"project", "TEXT", ""},
+		{"billed_difference", "INTEGER", "UPDATE work_sessions SET billed_difference = difference"},
+		{"currency", "TEXT", "UPDATE work_sessions SET currency = 'EUR'"},
+		// money moved from REAL to integer cents; all supported currencies have two decimals.
+		// The REAL columns were nullable, and a missing amount is zero.
+		{"hourly_rate_cents", "INTEGER", "UPDATE work_sessions SET hourly_rate_cents = COALESCE(CAST(ROUND(hourly_rate * 100) AS INTEGER), 0)"},
+		{"earnings_cents", "INTEGER", "UPDATE work_sessions SET earnings_cents = COALESCE(CAST(ROUND(earnings * 100) AS INTEGER), 0)"},
+		{"timezone", "TEXT", ""},
+		{"user_name", "TEXT NOT NULL DEFAULT ''", ""},
+		{"user_email", "TEXT NOT NULL DEFAULT ''", ""},
+	}
+	if err := addMissingColumns(db, "work_sessions", sessionColumns); err != nil {
+		return err
+	}
+	// rows without a zone predate canonical timestamps
+	if err := repairTimestamps(db); err != nil {
+		return err
 	}
+	// drop the float columns once their values live in the cents columns
+	if err := dropColumns(db, "work_sessions", "hourly_rate", "earnings"); err != nil {
+		return err
+	}
+
+	projectColumns := []schemaColumn{
+		{"currency", "TEXT NOT NULL DEFAULT ''", ""},
+	}
+	return addMissingColumns(db, "projects", projectColumns)
 }
 
-// getDeviceID returns a simple identifier for the current host (used as created_by).
-func getDeviceID() string {
-	deviceID, err := os.Hostname()
+// tableColumns returns the set of column names of table.
+func tableColumns(db *sql.DB, table string) (map[string]bool, error) {
+	rows, err := db.Query("PRAGMA table_info(" + table + ")")
 	if err != nil {
-		return "Unknown"
+		return nil, err
 	}
-	return deviceID
+	defer rows.Close()
+
+	existing := map[string]bool{}
+	for rows.Next() {
+		var (
+			cid       int
+			name      string
+			colType   string
+			notNull   int
+			dfltValue sql.NullString
+			pk        int
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dfltValue, &pk); err != nil {
+			return nil, err
+		}
+		existing[name] = true
+	}
+	return existing, rows.Err()
 }
 
-// saveSession persists a session. start and end are time.Time so no parsing is required here.
-// difference is expected in seconds (int64), hourlyRate and earnings are float64.
-func saveSession(db *sql.DB, title string, description string, start time.Time, end time.Time, difference int64, hourlyRate float64, earnings float64) error {
-	sessionUUID := uuid.New().String()
-	deviceID := getDeviceID()
+// addMissingColumns adds every column of columns that table does not have yet.
+func addMissingColumns(db *sql.DB, table string, columns []schemaColumn) error {
+	existing, err := tableColumns(db, table)
+	if err != nil {
+		return err
+	}
 
-	query := `INSERT INTO work_sessions (uuid, title, description, start_time, end_time, start_unix, end_unix, difference, hourly_rate, earnings, created_by) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	for _, c := range columns {
+		if existing[c.name] {
+			continue
+		}
+		if _, err := db.Exec("ALTER TABLE " + table + " ADD COLUMN " + c.name + " " + c.definition); err != nil {
+			return err
+		}
+		if c.backfill != "" {
+			if _, err := db.Exec(c.backfill); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
 
-	_, err := db.Exec(query, sessionUUID, title, description, start.Format("2006-01-02 15:04"), end.Format("2006-01-02 15:04"), start.Unix(), end.Unix(), difference, hourlyRate, earnings, deviceID)
+// dropColumns removes the named columns from table if they still exist.
+func dropColumns(db *sql.DB, table string, names ...string) error {
+	existing, err := tableColumns(db, table)
 	if err != nil {
 		return err
 	}
+	for _, name := range names {
+		if !existing[name] {
+			continue
+		}
+		if _, err := db.Exec("ALTER TABLE " + table + " DROP COLUMN " + name); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-// parseExportTime parses input like "2006-01-02 15:04" and returns time in local location.
-func parseExportTime(input string) (time.Time, error) {
-	layout := "2006-01-02 15:04"
-	input = strings.TrimSpace(input)
-	if input == "" {
-		return time.Time{}, fmt.Errorf("empty input")
+// getDeviceID returns a simple identifier for the current host (used as created_by).
+func getDeviceID() string {
+	deviceID, err := os.Hostname()
+	if err != nil {
+		return "Unknown"
 	}
-	// use ParseInLocation so local timezone is applied
-	return time.ParseInLocation(layout, input, time.Local)
+	return deviceID
 }
 
-func exportSessions(db *sql.DB) fyne.CanvasObject {
-	statusLabel := widget.NewLabel("")
-	startExport := widget.NewEntry()
-	endExport := widget.NewEntry()
-	var exportCSVBtn, exportXLSXBtn, exportSortCSVBtn, exportSortXLSXBtn, sortBtn, cancelBtn *widget.Button
-
-	sortBtn = widget.NewButton("Sort by time range", func() {
-		startExport.Show()
-		endExport.Show()
-		exportSortCSVBtn.Show()
-		exportSortXLSXBtn.Show()
-		sortBtn.Hide()
-		exportCSVBtn.Hide()
-		exportXLSXBtn.Hide()
-	})
-
-	cancelBtn = widget.NewButton("Cancel", func() {
-		startExport.Hide()
-		endExport.Hide()
-		exportSortCSVBtn.Hide()
-		exportSortXLSXBtn.Hide()
-		sortBtn.Show()
-		exportCSVBtn.Show()
-		exportXLSXBtn.Show()
-		startExport.SetText("")
-		endExport.SetText("")
-		statusLabel.SetText("")
-	})
+// saveSession persists a new session recorded in the local zone. difference (raw)
+// and billed (rounded) are expected in seconds (int64), hourlyRate and earnings are
+// Money amounts in currency (ISO 4217 code).
+func saveSession(store SessionStore, title string, description string, project string, start time.Time, end time.Time, difference int64, billed int64, hourlyRate Money, earnings Money, currency string) error {
+	s := newSession(title, description, project, start, end, localZoneName())
+	s.Difference = difference
+	s.BilledDifference = billed
+	s.HourlyRate = hourlyRate
+	s.Earnings = earnings
+	s.Currency = currency
+	return store.Create(&s)
+}
 
-	exportCSVBtn = widget.NewButton("Export to CSV", func() {
-		filename := "export_" + time.Now().Format("2006-01-02_15-04-05") + ".csv"
-		parent := fyne.CurrentApp().Driver().AllWindows()[0]
-		fd := dialog.NewFileSave(func(w fyne.URIWriteCloser, err error) {
-			if w == nil {
-				return // user cancelled
-			}
-			defer w.Close()
+// exportSessions builds the export tab: column chooser, formats, optional time
+// range and saved presets. Defaults (CSV delimiter, folder) come from the settings.
+func exportSessions(db *sql.DB, store SessionStore, cfg *Config) fyne.CanvasObject {
+	statusLabel := widget.NewLabel("")
+	startExport := newTimeEntry("Range start (empty: all sessions)")
+	endExport := newTimeEntry("Range end (empty: now)")
 
-			// write BOM + CSV
-			_, _ = w.Write([]byte{0xEF, 0xBB, 0xBF})
-			writer := csv.NewWriter(w)
-			writer.Comma = ';'
+	// column chooser
+	headers := make([]string, len(exportColumns))
+	for i, col := range exportColumns {
+		headers[i] = col.Header
+	}
+	columnChecks := widget.NewCheckGroup(headers, nil)
+	columnChecks.Horizontal = true
 
-			header := []string{"Title", "Description", "Start Time", "End Time", "Duration", "Hourly Rate (€)", "Earnings (€)"}
-			if err := writer.Write(header); err != nil {
-				statusLabel.SetText("Error writing header: " + err.Error())
-				return
+	// formats
+	formatSelect := widget.NewSelect(exportTargets(), nil)
+	refreshFormatsBtn := widget.NewButtonWithIcon("", theme.ViewRefreshIcon(), func() {
+		formatSelect.SetOptions(exportTargets())
+	})
+	dateFormatEntry := widget.NewSelectEntry(dateLayouts)
+	dateFormatEntry.SetPlaceHolder("Date format (Go layout, default " + displayLayout + ")")
+	durationSelect := widget.NewSelect(durationFormats, nil)
+	decimalSelect := widget.NewSelect([]string{".", ","}, nil)
+	delimiterEntry := widget.NewEntry()
+	delimiterEntry.SetPlaceHolder("CSV delimiter (empty: " + string(cfg.csvDelimiter()) + ")")
+	projectFilter := widget.NewSelectEntry(getProjectNames(db))
+	projectFilter.SetPlaceHolder("Only project (empty: all projects)")
+	rangeModeSelect := newRangeModeSelect()
+
+	// setForm shows a preset, getForm reads the current options
+	setForm := func(p ExportPreset) {
+		cols, err := p.columns()
+		if err != nil {
+			statusLabel.SetText("Error in preset: " + err.Error())
+			cols, _ = ExportPreset{}.columns()
+		}
+		var selected []string
+		for _, col := range cols {
+			selected = append(selected, col.Header)
+		}
+		columnChecks.SetSelected(selected)
+		formatSelect.SetSelected(p.Format)
+		dateFormatEntry.SetText(p.DateFormat)
+		if p.DurationFormat == "" {
+			p.DurationFormat = durationClock
+		}
+		durationSelect.SetSelected(p.DurationFormat)
+		if p.DecimalSeparator == "" {
+			p.DecimalSeparator = "."
+		}
+		decimalSelect.SetSelected(p.DecimalSeparator)
+		delimiterEntry.SetText(p.CSVDelimiter)
+		projectFilter.SetText(p.Project)
+		mode, _ := parseRangeMode(p.RangeMode)
+		rangeModeSelect.SetSelected(string(mode))
+		startExport.SetText(p.From)
+		endExport.SetText(p.To)
+	}
+	getForm := func() ExportPreset {
+		p := ExportPreset{
+			Format:           formatSelect.Selected,
+			DateFormat:       strings.TrimSpace(dateFormatEntry.Text),
+			DurationFormat:   durationSelect.Selected,
+			DecimalSeparator: decimalSelect.Selected,
+			CSVDelimiter:     delimiterEntry.Text,
+			Project:          strings.TrimSpace(projectFilter.Text),
+			RangeMode:        rangeModeSelect.Selected,
+			From:             strings.TrimSpace(startExport.Text),
+			To:               strings.TrimSpace(endExport.Text),
+		}
+		// keep the export order, not the click order
+		for _, col := range exportColumns {
+			if slices.Contains(columnChecks.Selected, col.Header) {
+				p.Columns = append(p.Columns, col.Key)
 			}
+		}
+		return p
+	}
+	setForm(ExportPreset{Format: exportCSV})
 
-			sessions := getAllSessions(db)
-			for _, s := range sessions {
-				row := []string{s.Title, s.Description, s.StartTime, s.EndTime, (time.Duration(s.Difference) * time.Second).String(), fmt.Sprintf("%.2f", s.HourlyRate), fmt.Sprintf("%.2f", s.Earnings)}
-				if err := writer.Write(row); err != nil {
-					statusLabel.SetText("Error writing row: " + err.Error())
-					return
-				}
-			}
-			writer.Flush()
-			if err := writer.Error(); err != nil {
-				statusLabel.SetText("Error finalizing CSV: " + err.Error())
-				return
-			}
-			statusLabel.SetText(fmt.Sprintf("Exported %d sessions to %s", len(sessions), w.URI().Name()))
-		}, parent)
-		fd.SetFileName(filename)
-		fd.Show()
+	// presets
+	presetSelect := widget.NewSelect(cfg.exportPresetNames(), func(name string) {
+		if p, ok := cfg.exportPreset(name); ok {
+			setForm(p)
+			statusLabel.SetText("Loaded preset " + name)
+		}
 	})
-
-	// replace exportSortCSVBtn handler (uses parsed time range)
-	exportSortCSVBtn = widget.NewButton("Export to CSV", func() {
-		startT, err := parseExportTime(startExport.Text)
-		if err != nil {
-			statusLabel.SetText("Invalid start time. Use format: 2006-01-02 15:04")
+	presetSelect.PlaceHolder = "(no preset)"
+	presetNameEntry := widget.NewEntry()
+	presetNameEntry.SetPlaceHolder("Preset name")
+
+	savePresetBtn := widget.NewButton("Save preset", func() {
+		p := getForm()
+		p.Name = strings.TrimSpace(presetNameEntry.Text)
+		if p.Name == "" {
+			p.Name = presetSelect.Selected
+		}
+		if p.Name == "" {
+			statusLabel.SetText("Enter a preset name")
 			return
 		}
-		endT, err := parseExportTime(endExport.Text)
-		if err != nil {
-			statusLabel.SetText("Invalid end time. Use format: 2006-01-02 15:04")
+		if len(p.Columns) == 0 {
+			statusLabel.SetText("Select at least one column")
 			return
 		}
-		if endT.Before(startT) {
-			statusLabel.SetText("End time must be after start time")
+		if err := p.validate(); err != nil {
+			statusLabel.SetText("Invalid preset: " + err.Error())
 			return
 		}
-
-		filename := "export_" + time.Now().Format("2006-01-02_15-04-05") + ".csv"
-		parent := fyne.CurrentApp().Driver().AllWindows()[0]
-		fd := dialog.NewFileSave(func(w fyne.URIWriteCloser, err error) {
-			if w == nil {
-				return
-			}
-			defer w.Close()
-
-			_, _ = w.Write([]byte{0xEF, 0xBB, 0xBF})
-			writer := csv.NewWriter(w)
-			writer.Comma = ';'
-			header := []string{"Title", "Description", "Start Time", "End Time", "Duration", "Hourly Rate (€)", "Earnings (€)"}
-			if err := writer.Write(header); err != nil {
-				statusLabel.SetText("Error writing header: " + err.Error())
-				return
-			}
-
-			sessions := getAllSessions(db)
-			exported := 0
-			for _, s := range sessions {
-				if s.startUnix < startT.Unix() || s.endUnix > endT.Unix() {
-					continue
-				}
-				row := []string{s.Title, s.Description, s.StartTime, s.EndTime, (time.Duration(s.Difference) * time.Second).String(), fmt.Sprintf("%.2f", s.HourlyRate), fmt.Sprintf("%.2f", s.Earnings)}
-				if err := writer.Write(row); err != nil {
-					statusLabel.SetText("Error writing row: " + err.Error())
-					return
-				}
-				exported++
-			}
-			writer.Flush()
-			if err := writer.Error(); err != nil {
-				statusLabel.SetText("Error finalizing CSV: " + err.Error())
-				return
-			}
-			statusLabel.SetText(fmt.Sprintf("Exported %d sessions to %s", exported, w.URI().Name()))
-		}, parent)
-		fd.SetFileName(filename)
-		fd.Show()
+		cfg.setExportPreset(p)
+		if err := cfg.save(); err != nil {
+			statusLabel.SetText("Error saving preset: " + err.Error())
+			return
+		}
+		presetSelect.SetOptions(cfg.exportPresetNames())
+		presetSelect.SetSelected(p.Name)
+		presetNameEntry.SetText("")
+		statusLabel.SetText("Saved preset " + p.Name)
 	})
 
-	// replace exportXLSXBtn handler
-	exportXLSXBtn = widget.NewButton("Export to XLSX", func() {
-		filename := "export_" + time.Now().Format("2006-01-02_15-04-05") + ".xlsx"
-		parent := fyne.CurrentApp().Driver().AllWindows()[0]
-		fd := dialog.NewFileSave(func(w fyne.URIWriteCloser, err error) {
-			if w == nil {
-				return
-			}
-			defer w.Close()
-
-			sessions := getAllSessions(db)
-			f := excelize.NewFile()
-			defer f.Close()
-			sheet := "Sheet1"
-			headers := []string{"Title", "Description", "Start Time", "End Time", "Duration", "Hourly Rate", "Earnings"}
-			for i, h := range headers {
-				cell := string(rune('A'+i)) + "1"
-				f.SetCellValue(sheet, cell, h)
-			}
-			for rowIdx, s := range sessions {
-				row := rowIdx + 2
-				rowStr := strconv.Itoa(row)
-				f.SetCellValue(sheet, "A"+rowStr, s.Title)
-				f.SetCellValue(sheet, "B"+rowStr, s.Description)
-				f.SetCellValue(sheet, "C"+rowStr, s.StartTime)
-				f.SetCellValue(sheet, "D"+rowStr, s.EndTime)
-				f.SetCellValue(sheet, "E"+rowStr, (time.Duration(s.Difference) * time.Second).String())
-				f.SetCellValue(sheet, "F"+rowStr, s.HourlyRate)
-				f.SetCellValue(sheet, "G"+rowStr, s.Earnings)
-			}
-			// write excel file to writer
-			if _, err := f.WriteTo(w); err != nil {
-				statusLabel.SetText("Error writing XLSX: " + err.Error())
-				return
-			}
-			statusLabel.SetText(fmt.Sprintf("Exported %d sessions to %s", len(sessions), w.URI().Name()))
-		}, parent)
-		fd.SetFileName(filename)
-		fd.Show()
+	deletePresetBtn := widget.NewButton("Delete preset", func() {
+		name := presetSelect.Selected
+		if name == "" {
+			statusLabel.SetText("Select a preset to delete")
+			return
+		}
+		cfg.deleteExportPreset(name)
+		if err := cfg.save(); err != nil {
+			statusLabel.SetText("Error deleting preset: " + err.Error())
+			return
+		}
+		presetSelect.ClearSelected()
+		presetSelect.SetOptions(cfg.exportPresetNames())
+		statusLabel.SetText("Deleted preset " + name)
 	})
 
-	// replace exportSortXLSXBtn handler
-	exportSortXLSXBtn = widget.NewButton("Export to XLSX", func() {
-		startT, err := parseExportTime(startExport.Text)
-		if err != nil {
-			statusLabel.SetText("Invalid start time. Use format: 2006-01-02 15:04")
+	exportBtn := widget.NewButton("Export", func() {
+		p := getForm()
+		p.Name = presetSelect.Selected
+		if len(p.Columns) == 0 {
+			statusLabel.SetText("Select at least one column")
 			return
 		}
-		endT, err := parseExportTime(endExport.Text)
-		if err != nil {
-			statusLabel.SetText("Invalid end time. Use format: 2006-01-02 15:04")
+		if err := p.validate(); err != nil {
+			statusLabel.SetText("Invalid export options: " + err.Error())
 			return
 		}
-		if endT.Before(startT) {
-			statusLabel.SetText("End time must be after start time")
+		if _, _, _, err := p.timeRange(time.Now()); err != nil {
+			statusLabel.SetText("Invalid time range: " + err.Error())
 			return
 		}
-
-		filename := "export_" + time.Now().Format("2006-01-02_15-04-05") + ".xlsx"
-		parent := fyne.CurrentApp().Driver().AllWindows()[0]
-		fd := dialog.NewFileSave(func(w fyne.URIWriteCloser, err error) {
-			if w == nil {
-				return
-			}
-			defer w.Close()
-
-			sessions := getAllSessions(db)
-			f := excelize.NewFile()
-			defer f.Close()
-			sheet := "Sheet1"
-			headers := []string{"Title", "Description", "Start Time", "End Time", "Duration", "Hourly Rate", "Earnings"}
-			for i, h := range headers {
-				cell := string(rune('A'+i)) + "1"
-				f.SetCellValue(sheet, cell, h)
-			}
-			rowNo := 2
-			exported := 0
-			for _, s := range sessions {
-				if s.startUnix < startT.Unix() || s.endUnix > endT.Unix() {
-					continue
-				}
-				rowStr := strconv.Itoa(rowNo)
-				f.SetCellValue(sheet, "A"+rowStr, s.Title)
-				f.SetCellValue(sheet, "B"+rowStr, s.Description)
-				f.SetCellValue(sheet, "C"+rowStr, s.StartTime)
-				f.SetCellValue(sheet, "D"+rowStr, s.EndTime)
-				f.SetCellValue(sheet, "E"+rowStr, (time.Duration(s.Difference) * time.Second).String())
-				f.SetCellValue(sheet, "F"+rowStr, s.HourlyRate)
-				f.SetCellValue(sheet, "G"+rowStr, s.Earnings)
-				rowNo++
-				exported++
-			}
-			if _, err := f.WriteTo(w); err != nil {
-				statusLabel.SetText("Error writing XLSX: " + err.Error())
-				return
+		showExportDialog(cfg, exportFileName(p), statusLabel, func(w fyne.URIWriteCloser) (string, error) {
+			exported, err := runExport(store, cfg, p, w, time.Now())
+			if err != nil {
+				return "", err
 			}
-			statusLabel.SetText(fmt.Sprintf("Exported %d sessions to %s", exported, w.URI().Name()))
-		}, parent)
-		fd.SetFileName(filename)
-		fd.Show()
+			return fmt.Sprintf("Exported %d sessions to %s", exported, w.URI().Name()), nil
+		})
 	})
 
-	exportSortCSVBtn.Hide()
-	exportSortXLSXBtn.Hide()
-	startExport.Hide()
-	endExport.Hide()
-	cancelBtn.Hide()
-	exportXLSXBtn.Show()
-	exportCSVBtn.Show()
-	startExport.SetPlaceHolder("Format: 2006-01-02 15:04")
-	endExport.SetPlaceHolder("Format: 2006-01-02 15:04")
-
-	return container.NewVBox(
+	return container.NewVScroll(container.NewVBox(
 		statusLabel,
-		exportCSVBtn,
-		exportXLSXBtn,
-		sortBtn,
+		widget.NewLabel("Preset"),
+		container.NewHBox(presetSelect, deletePresetBtn),
+		container.NewBorder(nil, nil, nil, savePresetBtn, presetNameEntry),
+		widget.NewSeparator(),
+		widget.NewLabel("Columns"),
+		columnChecks,
+		widget.NewSeparator(),
+		container.NewHBox(widget.NewLabel("Format:"), formatSelect, refreshFormatsBtn),
+		widget.NewLabel("Templates (*.tmpl) in "+templateDir()+" are listed as formats"),
+		dateFormatEntry,
+		container.NewHBox(widget.NewLabel("Durations:"), durationSelect, widget.NewLabel("Decimal separator:"), decimalSelect),
+		delimiterEntry,
+		widget.NewSeparator(),
+		projectFilter,
 		startExport,
 		endExport,
-		exportSortCSVBtn,
-		exportSortXLSXBtn,
-		cancelBtn,
-	)
+		container.NewHBox(widget.NewLabel("Sessions on the range edges:"), rangeModeSelect),
+		exportBtn,
+	))
 }
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The metrics endpoint serves the Prometheus text exposition format, which
+// is simple enough to write by hand:
+// https://prometheus.io/docs/instrumenting/exposition_formats/
+
+// metricsSource holds what /metrics reports: the timer, as told by the
+// timer events, and the sessions of the open workspace.
+type metricsSource struct {
+	cfg     *Config
+	mu      sync.Mutex
+	store   SessionStore
+	cipher  *fieldCipher // of store; nil when no workspace is open
+	running bool
+	started time.Time
+}
+
+// setStore switches to the sessions of another workspace, whose fields are
+// encrypted by fc. Its timer is not running yet.
+func (m *metricsSource) setStore(store SessionStore, fc *fieldCipher) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.store = store
+	m.cipher = fc
+	m.running = false
+}
+
+// withTitles reports whether series are labeled with session titles. The
+// endpoint has no authentication, so titles are only shown when the settings
+// allow it and never for an encrypted workspace.
+func (m *metricsSource) withTitles(fc *fieldCipher) bool {
+	return m.cfg != nil && m.cfg.MetricsTitles && (fc == nil || !fc.enabled())
+}
+
+// notify follows the timer events.
+func (m *metricsSource) notify(event string, s Session) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	switch event {
+	case eventTimerStarted:
+		m.running = true
+		m.started = s.Start()
+	case eventTimerStopped:
+		m.running = false
+	}
+}
+
+// sessionSeries identifies the tracked time of one title, project and device.
+// The title is empty when series are not labeled with titles.
+type sessionSeries struct {
+	title, project, createdBy string
+}
+
+// earningsSeries also needs the currency; amounts are never summed across currencies.
+type earningsSeries struct {
+	sessionSeries
+	currency string
+}
+
+// metricsWriter writes the exposition format.
+type metricsWriter struct {
+	bytes.Buffer
+}
+
+// family starts a metric with its help text and type.
+func (w *metricsWriter) family(name, kind, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+// sample writes one value; labels are name and value pairs.
+func (w *metricsWriter) sample(name string, value float64, labels ...string) {
+	w.WriteString(name)
+	if len(labels) > 0 {
+		w.WriteByte('{')
+		for i := 0; i < len(labels); i += 2 {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			w.WriteString(labels[i] + `="` + escapeLabel(labels[i+1]) + `"`)
+		}
+		w.WriteByte('}')
+	}
+	w.WriteString(" " + strconv.FormatFloat(value, 'f', -1, 64) + "\n")
+}
+
+// labelEscaper escapes label values as the exposition format requires.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(v string) string {
+	return labelEscaper.Replace(v)
+}
+
+// writeMetrics reports the timer and the totals of all sessions at now.
+func (m *metricsSource) writeMetrics(w *metricsWriter, now time.Time) error {
+	m.mu.Lock()
+	store, fc, running, started := m.store, m.cipher, m.running, m.started
+	m.mu.Unlock()
+
+	w.family("tasktracker_timer_running", "gauge", "Whether the timer is running (1) or not (0).")
+	var elapsed float64
+	if running {
+		w.sample("tasktracker_timer_running", 1)
+		elapsed = now.Sub(started).Seconds()
+	} else {
+		w.sample("tasktracker_timer_running", 0)
+	}
+	w.family("tasktracker_timer_elapsed_seconds", "gauge", "Seconds since the running timer was started.")
+	w.sample("tasktracker_timer_elapsed_seconds", float64(int64(elapsed)))
+
+	if store == nil {
+		return nil
+	}
+	sessions, err := store.List(SessionFilter{})
+	if err != nil {
+		return err
+	}
+	count := map[sessionSeries]int{}
+	tracked := map[sessionSeries]int64{}
+	billed := map[sessionSeries]int64{}
+	earned := map[earningsSeries]Money{}
+	titles := m.withTitles(fc)
+	for _, s := range sessions {
+		key := sessionSeries{project: s.Project, createdBy: s.CreatedBy}
+		if titles {
+			key.title = s.Title
+		}
+		count[key]++
+		tracked[key] += s.Difference
+		billed[key] += s.BilledDifference
+		earned[earningsSeries{key, s.Currency}] += s.Earnings
+	}
+
+	keys := make([]sessionSeries, 0, len(count))
+	for key := range count {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].less(keys[j]) })
+	labels := func(key sessionSeries) []string {
+		if titles {
+			return []string{"title", key.title, "project", key.project, "created_by", key.createdBy}
+		}
+		return []string{"project", key.project, "created_by", key.createdBy}
+	}
+
+	w.family("tasktracker_sessions", "gauge", "Number of saved sessions.")
+	for _, key := range keys {
+		w.sample("tasktracker_sessions", float64(count[key]), labels(key)...)
+	}
+	// the totals are gauges, not counters: editing or deleting a session lowers them
+	w.family("tasktracker_tracked_seconds", "gauge", "Tracked time of the saved sessions in seconds.")
+	for _, key := range keys {
+		w.sample("tasktracker_tracked_seconds", float64(tracked[key]), labels(key)...)
+	}
+	w.family("tasktracker_billed_seconds", "gauge", "Billed time of the saved sessions in seconds, after rounding.")
+	for _, key := range keys {
+		w.sample("tasktracker_billed_seconds", float64(billed[key]), labels(key)...)
+	}
+
+	earnedKeys := make([]earningsSeries, 0, len(earned))
+	for key := range earned {
+		earnedKeys = append(earnedKeys, key)
+	}
+	sort.Slice(earnedKeys, func(i, j int) bool {
+		if earnedKeys[i].sessionSeries != earnedKeys[j].sessionSeries {
+			return earnedKeys[i].less(earnedKeys[j].sessionSeries)
+		}
+		return earnedKeys[i].currency < earnedKeys[j].currency
+	})
+	w.family("tasktracker_earnings", "gauge", "Earnings of the saved sessions in units of their currency.")
+	for _, key := range earnedKeys {
+		w.sample("tasktracker_earnings", earned[key].Float(), append(labels(key.sessionSeries), "currency", key.currency)...)
+	}
+	return nil
+}
+
+// less orders series by project, title and device.
+func (a sessionSeries) less(b sessionSeries) bool {
+	if a.project != b.project {
+		return a.project < b.project
+	}
+	if a.title != b.title {
+		return a.title < b.title
+	}
+	return a.createdBy < b.createdBy
+}
+
+// ServeHTTP answers scrapes.
+func (m *metricsSource) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	var w metricsWriter
+	if err := m.writeMetrics(&w, time.Now()); err != nil {
+		slog.Error("collecting metrics", "err", err)
+		http.Error(rw, "reading sessions failed", http.StatusInternalServerError)
+		return
+	}
+	rw.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	rw.Write(w.Bytes())
+}
+
+// serveMetrics listens on addr and serves m at /metrics in the background.
+// Only a listen error is returned; later errors are logged.
+func serveMetrics(addr string, m *metricsSource) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", m)
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	slog.Info("serving metrics", "addr", ln.Addr().String())
+	go func() {
+		if err := srv.Serve(ln); err != nil {
+			slog.Error("metrics endpoint stopped", "err", err)
+		}
+	}()
+	return nil
+}
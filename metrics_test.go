@@ -0,0 +1,57 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMetricsTitles(t *testing.T) {
+	unlocked := &fieldCipher{}
+	if err := unlocked.setKey(make([]byte, 32)); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name       string
+		setting    bool
+		fc         *fieldCipher
+		wantTitles bool
+	}{
+		{"off by default", false, &fieldCipher{}, false},
+		{"on in the settings", true, &fieldCipher{}, true},
+		{"never for encrypted workspaces", true, unlocked, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newMemoryStore()
+			createTestSession(t, store, "Secret client", "Website", testTime(9, 0), testTime(10, 0))
+			createTestSession(t, store, "Other", "Website", testTime(11, 0), testTime(11, 30))
+			m := &metricsSource{cfg: &Config{MetricsTitles: tt.setting}}
+			m.setStore(store, tt.fc)
+
+			var w metricsWriter
+			if err := m.writeMetrics(&w, testTime(12, 0)); err != nil {
+				t.Fatal(err)
+			}
+			out := w.String()
+			if got := strings.Contains(out, "Secret client"); got != tt.wantTitles {
+				t.Errorf("titles in output = %v, want %v:\n%s", got, tt.wantTitles, out)
+			}
+			// without titles, sessions of a project and device add up to one series
+			wantTracked := `tasktracker_tracked_seconds{project="Website",created_by="` + getDeviceID() + `"} 5400`
+			if tt.wantTitles {
+				wantTracked = `tasktracker_tracked_seconds{title="Secret client",project="Website",created_by="` + getDeviceID() + `"} 3600`
+			}
+			if !strings.Contains(out, wantTracked+"\n") {
+				t.Errorf("missing %s in:\n%s", wantTracked, out)
+			}
+			for _, name := range []string{"tasktracker_tracked_seconds", "tasktracker_billed_seconds", "tasktracker_earnings"} {
+				if !strings.Contains(out, "# TYPE "+name+" gauge\n") {
+					t.Errorf("%s is not a gauge:\n%s", name, out)
+				}
+			}
+			if strings.Contains(out, "counter") {
+				t.Errorf("totals that can go down are typed as counters:\n%s", out)
+			}
+		})
+	}
+}
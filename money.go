@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Money is an amount in minor units (cents). All supported currencies use two
+// decimals, so the currency is stored next to the amount instead of inside it.
+// Calculations stay in integers; rounding only happens in earningsFor.
+type Money int64
+
+// parseMoney parses user input like "45", "45.5" or "45,50" into Money.
+// More than two decimals are rejected instead of silently rounded.
+func parseMoney(input string) (Money, error) {
+	s := strings.TrimSpace(input)
+	if s == "" {
+		return 0, fmt.Errorf("empty amount")
+	}
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+	s = strings.Replace(s, ",", ".", 1)
+
+	intPart, fracPart, _ := strings.Cut(s, ".")
+	if intPart == "" {
+		intPart = "0"
+	}
+	if strings.ContainsAny(intPart+fracPart, "+-") {
+		return 0, fmt.Errorf("invalid amount %q", input)
+	}
+	if len(fracPart) > 2 {
+		return 0, fmt.Errorf("amount %q has more than two decimals", input)
+	}
+	for len(fracPart) < 2 {
+		fracPart += "0"
+	}
+	units, err := strconv.ParseInt(intPart, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q", input)
+	}
+	cents, err := strconv.ParseInt(fracPart, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q", input)
+	}
+	m := Money(units*100 + cents)
+	if negative {
+		m = -m
+	}
+	return m, nil
+}
+
+// moneyFromFloat converts a legacy float amount, rounding half away from zero to cents.
+func moneyFromFloat(f float64) Money {
+	return Money(math.Round(f * 100))
+}
+
+// earningsFor returns rate (per hour) times d, rounded half away from zero to
+// whole cents. Only whole seconds of d are billed.
+func earningsFor(rate Money, d time.Duration) Money {
+	seconds := int64(d / time.Second)
+	product := int64(rate) * seconds
+	if product < 0 {
+		return -Money((-product*2 + 3600) / 7200)
+	}
+	return Money((product*2 + 3600) / 7200)
+}
+
+// Decimal returns the amount as a plain decimal string like "1234.50" for exports.
+func (m Money) Decimal() string {
+	sign := ""
+	v := int64(m)
+	if v < 0 {
+		sign = "-"
+		v = -v
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, v/100, v%100)
+}
+
+// Float returns the amount in major units for spreadsheet cells. Do not use it for arithmetic.
+func (m Money) Float() float64 {
+	return float64(m) / 100
+}
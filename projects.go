@@ -0,0 +1,289 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Project groups sessions and carries per-project billing settings.
+// An empty Currency means the global default currency is used.
+type Project struct {
+	Name     string
+	Rounding RoundingPolicy
+	Currency string
+}
+
+// createProjectsTable ensures the projects table exists.
+func createProjectsTable(db *sql.DB) error {
+	query := `
+    CREATE TABLE IF NOT EXISTS projects (
+        name TEXT PRIMARY KEY,
+        rounding_mode TEXT NOT NULL DEFAULT 'inherit',
+        rounding_minutes INTEGER NOT NULL DEFAULT 0,
+        minimum_minutes INTEGER NOT NULL DEFAULT 0,
+        currency TEXT NOT NULL DEFAULT ''
+    );`
+	_, err := db.Exec(query)
+	return err
+}
+
+// getProjects returns all projects ordered by name.
+func getProjects(db *sql.DB) ([]Project, error) {
+	rows, err := db.Query("SELECT name, rounding_mode, rounding_minutes, minimum_minutes, currency FROM projects ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var projects []Project
+	for rows.Next() {
+		var p Project
+		if err := rows.Scan(&p.Name, &p.Rounding.Mode, &p.Rounding.Minutes, &p.Rounding.MinimumMinutes, &p.Currency); err != nil {
+			return nil, err
+		}
+		projects = append(projects, p)
+	}
+	return projects, rows.Err()
+}
+
+// getProjectNames returns only the project names, for select entries.
+func getProjectNames(db *sql.DB) []string {
+	projects, err := getProjects(db)
+	if err != nil {
+		return nil
+	}
+	names := make([]string, 0, len(projects))
+	for _, p := range projects {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+// getProject looks up a project by name. found is false if it does not exist.
+func getProject(db *sql.DB, name string) (Project, bool, error) {
+	var p Project
+	row := db.QueryRow("SELECT name, rounding_mode, rounding_minutes, minimum_minutes, currency FROM projects WHERE name = ?", name)
+	err := row.Scan(&p.Name, &p.Rounding.Mode, &p.Rounding.Minutes, &p.Rounding.MinimumMinutes, &p.Currency)
+	if err == sql.ErrNoRows {
+		return p, false, nil
+	}
+	if err != nil {
+		return p, false, err
+	}
+	return p, true, nil
+}
+
+// saveProject inserts or replaces a project.
+func saveProject(db *sql.DB, p Project) error {
+	query := `INSERT INTO projects (name, rounding_mode, rounding_minutes, minimum_minutes, currency) VALUES (?, ?, ?, ?, ?)
+	ON CONFLICT(name) DO UPDATE SET rounding_mode = excluded.rounding_mode, rounding_minutes = excluded.rounding_minutes, minimum_minutes = excluded.minimum_minutes, currency = excluded.currency`
+	_, err := db.Exec(query, p.Name, p.Rounding.Mode, p.Rounding.Minutes, p.Rounding.MinimumMinutes, p.Currency)
+	return err
+}
+
+// deleteProject removes a project. Sessions keep their project name.
+func deleteProject(db *sql.DB, name string) error {
+	_, err := db.Exec("DELETE FROM projects WHERE name = ?", name)
+	return err
+}
+
+// roundingFor returns the rounding policy that applies to sessions of project.
+// Unknown projects and projects set to inherit use the global policy.
+func roundingFor(db *sql.DB, cfg *Config, project string) (RoundingPolicy, error) {
+	if project == "" {
+		return cfg.Rounding, nil
+	}
+	p, found, err := getProject(db, project)
+	if err != nil {
+		return cfg.Rounding, err
+	}
+	if !found || p.Rounding.Mode == roundingInherit || p.Rounding.Mode == "" {
+		return cfg.Rounding, nil
+	}
+	return p.Rounding, nil
+}
+
+// currencyFor returns the default currency for new sessions of project:
+// the project's currency if set, otherwise the global default.
+func currencyFor(db *sql.DB, cfg *Config, project string) string {
+	if project != "" {
+		p, found, err := getProject(db, project)
+		if err == nil && found && p.Currency != "" {
+			return p.Currency
+		}
+	}
+	if cfg.Currency != "" {
+		return cfg.Currency
+	}
+	return defaultCurrency
+}
+
+// billedDuration applies the project's (or global) rounding policy to the raw duration.
+func billedDuration(db *sql.DB, cfg *Config, project string, raw time.Duration) (time.Duration, error) {
+	policy, err := roundingFor(db, cfg, project)
+	if err != nil {
+		return raw, err
+	}
+	return policy.Apply(raw), nil
+}
+
+// newRoundingForm builds the inputs used to edit a RoundingPolicy.
+// It returns the form object plus getter and setter for the policy.
+func newRoundingForm(modes []string) (fyne.CanvasObject, func() (RoundingPolicy, error), func(RoundingPolicy)) {
+	modeSelect := widget.NewSelect(modes, nil)
+	minutesEntry := widget.NewEntry()
+	minutesEntry.SetPlaceHolder("Increment in minutes (e.g. 6 or 15)")
+	minimumEntry := widget.NewEntry()
+	minimumEntry.SetPlaceHolder("Minimum billable minutes (optional)")
+
+	get := func() (RoundingPolicy, error) {
+		var p RoundingPolicy
+		p.Mode = modeSelect.Selected
+		if p.Mode == "" {
+			p.Mode = modes[0]
+		}
+		if s := strings.TrimSpace(minutesEntry.Text); s != "" {
+			m, err := strconv.Atoi(s)
+			if err != nil || m < 0 {
+				return p, fmt.Errorf("invalid increment %q", s)
+			}
+			p.Minutes = m
+		}
+		if s := strings.TrimSpace(minimumEntry.Text); s != "" {
+			m, err := strconv.Atoi(s)
+			if err != nil || m < 0 {
+				return p, fmt.Errorf("invalid minimum %q", s)
+			}
+			p.MinimumMinutes = m
+		}
+		if (p.Mode == roundingUp || p.Mode == roundingDown || p.Mode == roundingNearest) && p.Minutes == 0 {
+			return p, fmt.Errorf("mode %q needs an increment", p.Mode)
+		}
+		return p, nil
+	}
+
+	set := func(p RoundingPolicy) {
+		if p.Mode == "" {
+			p.Mode = modes[0]
+		}
+		modeSelect.SetSelected(p.Mode)
+		minutesEntry.SetText("")
+		minimumEntry.SetText("")
+		if p.Minutes > 0 {
+			minutesEntry.SetText(strconv.Itoa(p.Minutes))
+		}
+		if p.MinimumMinutes > 0 {
+			minimumEntry.SetText(strconv.Itoa(p.MinimumMinutes))
+		}
+	}
+
+	form := container.NewVBox(
+		container.NewHBox(widget.NewLabel("Rounding:"), modeSelect),
+		minutesEntry,
+		minimumEntry,
+	)
+	return form, get, set
+}
+
+// createProjectsTab lets the user manage projects with their rounding policy and currency.
+// The global defaults they fall back to are edited on the settings tab.
+func createProjectsTab(db *sql.DB) fyne.CanvasObject {
+	statusLabel := widget.NewLabel("")
+	projectsList := container.NewVBox()
+
+	// project section
+	nameEntry := widget.NewEntry()
+	nameEntry.SetPlaceHolder("Project name...")
+	projectModes := append([]string{roundingInherit}, roundingModes...)
+	projectForm, getProjectRounding, setProjectRounding := newRoundingForm(projectModes)
+	setProjectRounding(RoundingPolicy{Mode: roundingInherit})
+	// the first option keeps the project on the global default currency
+	projectCurrency := widget.NewSelect(append([]string{"default"}, currencyCodes...), nil)
+	projectCurrency.SetSelected("default")
+
+	loadProjects := func() {
+		projectsList.RemoveAll()
+		projects, err := getProjects(db)
+		if err != nil {
+			statusLabel.SetText("Error loading projects: " + err.Error())
+			return
+		}
+		for _, p := range projects {
+			project := p
+			editBtn := widget.NewButton("Edit", func() {
+				nameEntry.SetText(project.Name)
+				setProjectRounding(project.Rounding)
+				if project.Currency == "" {
+					projectCurrency.SetSelected("default")
+				} else {
+					projectCurrency.SetSelected(project.Currency)
+				}
+			})
+			currency := project.Currency
+			if currency == "" {
+				currency = "default currency"
+			}
+			projectsList.Add(container.NewHBox(widget.NewLabel(project.Name+": "+project.Rounding.String()+", "+currency), editBtn))
+		}
+	}
+
+	saveProjectBtn := widget.NewButton("Save project", func() {
+		name := strings.TrimSpace(nameEntry.Text)
+		if name == "" {
+			statusLabel.SetText("Project name is required")
+			return
+		}
+		rounding, err := getProjectRounding()
+		if err != nil {
+			statusLabel.SetText("Invalid rounding: " + err.Error())
+			return
+		}
+		currency := projectCurrency.Selected
+		if currency == "default" {
+			currency = ""
+		}
+		if err := saveProject(db, Project{Name: name, Rounding: rounding, Currency: currency}); err != nil {
+			statusLabel.SetText("Error saving project: " + err.Error())
+			return
+		}
+		statusLabel.SetText("Project '" + name + "' saved")
+		nameEntry.SetText("")
+		setProjectRounding(RoundingPolicy{Mode: roundingInherit})
+		projectCurrency.SetSelected("default")
+		loadProjects()
+	})
+
+	deleteProjectBtn := widget.NewButton("Delete project", func() {
+		name := strings.TrimSpace(nameEntry.Text)
+		if name == "" {
+			statusLabel.SetText("Enter the project name to delete")
+			return
+		}
+		if err := deleteProject(db, name); err != nil {
+			statusLabel.SetText("Error deleting project: " + err.Error())
+			return
+		}
+		statusLabel.SetText("Project '" + name + "' deleted")
+		nameEntry.SetText("")
+		loadProjects()
+	})
+
+	loadProjects()
+
+	return container.NewVScroll(container.NewVBox(
+		statusLabel,
+		widget.NewLabel("Projects"),
+		projectsList,
+		nameEntry,
+		projectForm,
+		container.NewHBox(widget.NewLabel("Currency:"), projectCurrency),
+		container.NewHBox(saveProjectBtn, deleteProjectBtn),
+	))
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"time"
+
+	"fyne.io/fyne/v2/widget"
+)
+
+// RangeMode decides which sessions a time range selects.
+type RangeMode string
+
+const (
+	// rangeContained selects sessions lying entirely inside the range.
+	rangeContained RangeMode = "contained"
+	// rangeOverlapping selects every session that overlaps the range, unchanged.
+	rangeOverlapping RangeMode = "overlapping"
+	// rangeClipped selects overlapping sessions and cuts them to the range,
+	// prorating billed duration and earnings by the part inside.
+	rangeClipped RangeMode = "clipped"
+)
+
+var rangeModes = []RangeMode{rangeContained, rangeOverlapping, rangeClipped}
+
+// parseRangeMode reads a stored mode; empty means contained, the behaviour
+// before modes existed.
+func parseRangeMode(s string) (RangeMode, bool) {
+	if s == "" {
+		return rangeContained, true
+	}
+	for _, m := range rangeModes {
+		if string(m) == s {
+			return m, true
+		}
+	}
+	return "", false
+}
+
+// newRangeModeSelect returns a select for the range modes, preset to contained.
+func newRangeModeSelect() *widget.Select {
+	options := make([]string, len(rangeModes))
+	for i, m := range rangeModes {
+		options[i] = string(m)
+	}
+	sel := widget.NewSelect(options, nil)
+	sel.SetSelected(string(rangeContained))
+	return sel
+}
+
+// clipSession cuts s to [from, to). Duration becomes the part inside, billed
+// duration and earnings are prorated by that part of the raw duration.
+// Zero from or to leaves that side open.
+func clipSession(s Session, from, to time.Time) Session {
+	start, end := s.startUnix, s.endUnix
+	if !from.IsZero() && from.Unix() > start {
+		start = from.Unix()
+	}
+	if !to.IsZero() && to.Unix() < end {
+		end = to.Unix()
+	}
+	if start == s.startUnix && end == s.endUnix {
+		return s
+	}
+	if end < start {
+		end = start
+	}
+
+	whole := s.endUnix - s.startUnix
+	part := end - start
+	s.BilledDifference = prorate(s.BilledDifference, part, whole)
+	s.Earnings = Money(prorate(int64(s.Earnings), part, whole))
+	s.Difference = part
+	s.startUnix, s.endUnix = start, end
+	s.StartTime = formatSessionTime(start, s.TimeZone)
+	s.EndTime = formatSessionTime(end, s.TimeZone)
+	return s
+}
+
+// prorate returns v * part / whole, rounded half away from zero.
+func prorate(v, part, whole int64) int64 {
+	if whole <= 0 {
+		return v
+	}
+	p := v * part
+	if p < 0 {
+		return -((-p*2 + whole) / (2 * whole))
+	}
+	return (p*2 + whole) / (2 * whole)
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Range reports are rendered from Go templates. The built-in templates below
+// can be replaced by files with the same name in <config>/templates.
+const (
+	markdownReportTemplate = "report.md.tmpl"
+	htmlReportTemplate     = "report.html.tmpl"
+)
+
+// ReportData is passed to report and export templates.
+type ReportData struct {
+	Title     string
+	From, To  time.Time // zero when the report covers all sessions
+	Generated time.Time
+	Sessions  []Session // chronological
+	Days      []ReportDay
+	Titles    []*SessionSummary // totals per title
+	Projects  []*SessionSummary // totals per project, "" for none
+	Duration  time.Duration
+	Billed    time.Duration
+	Earnings  map[string]Money // by currency
+}
+
+// ReportDay groups the sessions started on one day.
+type ReportDay struct {
+	Date     time.Time
+	Sessions []Session
+	Duration time.Duration
+	Billed   time.Duration
+	Earnings map[string]Money
+}
+
+// newReportData groups sessions by their start day in the session's zone.
+func newReportData(sessions []Session, from, to time.Time) ReportData {
+	sorted := append([]Session(nil), sessions...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].startUnix < sorted[j].startUnix })
+
+	data := ReportData{
+		Title:     "Work report",
+		From:      from,
+		To:        to,
+		Generated: time.Now(),
+		Sessions:  sorted,
+		Earnings:  map[string]Money{},
+	}
+	if !from.IsZero() {
+		data.Title = "Work report " + from.Format("2006-01-02") + " to " + to.Format("2006-01-02")
+	}
+
+	byDate := map[string]*ReportDay{}
+	var dates []string
+	for _, s := range sorted {
+		start := s.Start()
+		key := start.Format("2006-01-02")
+		day := byDate[key]
+		if day == nil {
+			day = &ReportDay{Date: time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, start.Location()), Earnings: map[string]Money{}}
+			byDate[key] = day
+			dates = append(dates, key)
+		}
+		day.Sessions = append(day.Sessions, s)
+		day.Duration += s.Duration()
+		day.Billed += s.Billed()
+		day.Earnings[s.Currency] += s.Earnings
+
+		data.Duration += s.Duration()
+		data.Billed += s.Billed()
+		data.Earnings[s.Currency] += s.Earnings
+	}
+	sort.Strings(dates)
+	for _, key := range dates {
+		data.Days = append(data.Days, *byDate[key])
+	}
+	data.Titles = summarize(sorted, func(s Session) string { return s.Title })
+	data.Projects = summarize(sorted, func(s Session) string { return s.Project })
+	return data
+}
+
+// reportFuncs are available in report templates.
+var reportFuncs = map[string]any{
+	"duration": formatClockDuration,
+	"hours":    hours,
+	"money":    formatMoney,
+	"totals":   formatTotals,
+	"lines":    descriptionLines,
+	"date":     func(t time.Time, layout string) string { return t.Format(layout) },
+}
+
+// formatClockDuration shows d as hours and minutes like "1:05".
+func formatClockDuration(d time.Duration) string {
+	minutes := int64(d.Round(time.Minute) / time.Minute)
+	sign := ""
+	if minutes < 0 {
+		sign = "-"
+		minutes = -minutes
+	}
+	return fmt.Sprintf("%s%d:%02d", sign, minutes/60, minutes%60)
+}
+
+// descriptionLines splits a description into its non-empty lines.
+func descriptionLines(s string) []string {
+	var lines []string
+	for _, l := range strings.Split(s, "\n") {
+		if l = strings.TrimSpace(l); l != "" {
+			lines = append(lines, l)
+		}
+	}
+	return lines
+}
+
+// reportTemplate is satisfied by text/template and html/template templates.
+type reportTemplate interface {
+	Execute(w io.Writer, data any) error
+}
+
+// templateDir is where users put their own templates.
+func templateDir() string {
+	return filepath.Join(appConfigDir(), "templates")
+}
+
+// loadReportTemplate returns the user's template from templateDir if there is
+// one, otherwise the built-in one. HTML templates escape their values.
+func loadReportTemplate(name, builtin string) (reportTemplate, error) {
+	text := builtin
+	data, err := os.ReadFile(filepath.Join(templateDir(), name))
+	switch {
+	case err == nil:
+		text = string(data)
+	case !errors.Is(err, os.ErrNotExist):
+		return nil, err
+	}
+
+	if strings.HasSuffix(name, ".html.tmpl") {
+		return htmltemplate.New(name).Funcs(reportFuncs).Parse(text)
+	}
+	return template.New(name).Funcs(reportFuncs).Parse(text)
+}
+
+// writeMarkdownReport renders the Markdown report.
+func writeMarkdownReport(w io.Writer, data ReportData) error {
+	tmpl, err := loadReportTemplate(markdownReportTemplate, builtinMarkdownReport)
+	if err != nil {
+		return err
+	}
+	return tmpl.Execute(w, data)
+}
+
+// writeHTMLReport renders the self-contained HTML report.
+func writeHTMLReport(w io.Writer, data ReportData) error {
+	tmpl, err := loadReportTemplate(htmlReportTemplate, builtinHTMLReport)
+	if err != nil {
+		return err
+	}
+	return tmpl.Execute(w, data)
+}
+
+// builtinMarkdownReport lists sessions per day with descriptions as bullets.
+const builtinMarkdownReport = `# {{.Title}}
+{{range .Days}}
+## {{date .Date "Monday, 2006-01-02"}} ({{duration .Billed}} h, {{totals .Earnings}})
+{{range .Sessions}}
+- **{{.Title}}** {{date .Start "15:04"}}-{{date .End "15:04"}} ({{duration .Billed}} h{{if .Project}}, {{.Project}}{{end}})
+{{- range lines .Description}}
+  - {{.}}
+{{- end}}
+{{- end}}
+{{end}}
+**Total:** {{duration .Billed}} h billed ({{duration .Duration}} h tracked), {{totals .Earnings}}
+`
+
+// builtinHTMLReport is the same report as a page without external resources.
+const builtinHTMLReport = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; max-width: 50em; margin: 2em auto; color: #222; }
+h2 { border-bottom: 1px solid #ccc; font-size: 1.1em; }
+.meta { color: #666; font-weight: normal; }
+ul.sessions > li { margin-bottom: 0.4em; }
+.total { margin-top: 2em; font-weight: bold; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{range .Days}}
+<h2>{{date .Date "Monday, 2006-01-02"}} <span class="meta">{{duration .Billed}} h, {{totals .Earnings}}</span></h2>
+<ul class="sessions">
+{{range .Sessions}}<li><strong>{{.Title}}</strong> <span class="meta">{{date .Start "15:04"}}-{{date .End "15:04"}}, {{duration .Billed}} h{{if .Project}}, {{.Project}}{{end}}</span>
+{{with lines .Description}}<ul>{{range .}}<li>{{.}}</li>{{end}}</ul>{{end}}</li>
+{{end}}</ul>
+{{end}}
+<p class="total">Total: {{duration .Billed}} h billed ({{duration .Duration}} h tracked), {{totals .Earnings}}</p>
+</body>
+</html>
+`
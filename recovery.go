@@ -0,0 +1,173 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// maxShownProblems limits how many integrity problems the recovery view lists.
+const maxShownProblems = 10
+
+// checkIntegrity runs PRAGMA integrity_check and returns the problems SQLite
+// reports; none means the database is fine.
+func checkIntegrity(db *sql.DB) ([]string, error) {
+	rows, err := db.Query("PRAGMA integrity_check")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var problems []string
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			return nil, err
+		}
+		problems = append(problems, line)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(problems) == 1 && problems[0] == "ok" {
+		return nil, nil
+	}
+	return problems, nil
+}
+
+// moveAside renames a damaged database and its journal files to
+// <path>.damaged-<time>, so a new database can be created at path.
+// It returns the new name of the database file.
+func moveAside(path string, now time.Time) (string, error) {
+	aside := path + ".damaged-" + now.Format("20060102-150405")
+	if err := os.Rename(path, aside); err != nil {
+		return "", err
+	}
+	for _, suffix := range []string{"-wal", "-shm", "-journal"} {
+		if err := os.Rename(path+suffix, aside+suffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return aside, err
+		}
+	}
+	return aside, nil
+}
+
+// rebuildDatabase copies what SQLite can still read into a new file with
+// VACUUM INTO and puts the copy in place of the damaged database, which is
+// moved aside. db is closed on success.
+func rebuildDatabase(db *sql.DB, path string, now time.Time) (string, error) {
+	rebuilt := path + ".rebuilt"
+	os.Remove(rebuilt)
+	if _, err := db.Exec("VACUUM INTO ?", rebuilt); err != nil {
+		os.Remove(rebuilt)
+		return "", err
+	}
+	db.Close()
+	aside, err := moveAside(path, now)
+	if err != nil {
+		return "", err
+	}
+	return aside, os.Rename(rebuilt, path)
+}
+
+// startDatabase opens the database at path, checks its integrity and prepares
+// its schema, then calls ready. If the file cannot be read or the check finds
+// problems, the window shows the recovery options instead: retry (e.g. when
+// another program holds a lock), continue anyway, rebuild the readable data
+// into a new file or start with an empty database. Damaged files are kept.
+func startDatabase(w fyne.Window, path string, ready func(db *sql.DB)) {
+	db, err := openSQLite(path)
+	var problems []string
+	if err == nil {
+		problems, err = checkIntegrity(db)
+	}
+	if err == nil && len(problems) == 0 {
+		if err = prepareSchema(db); err == nil {
+			slog.Info("database opened", "path", path)
+			ready(db)
+			return
+		}
+	}
+
+	var message string
+	if err != nil {
+		slog.Error("cannot open database", "path", path, "err", err)
+		message = fmt.Sprintf("The database %s could not be opened:\n%v", path, err)
+	} else {
+		slog.Error("database integrity check failed", "path", path, "problems", problems)
+		shown := problems
+		if len(shown) > maxShownProblems {
+			shown = append(shown[:maxShownProblems:maxShownProblems], fmt.Sprintf("... and %d more", len(problems)-maxShownProblems))
+		}
+		message = fmt.Sprintf("The integrity check of %s found problems:\n%s", path, strings.Join(shown, "\n"))
+	}
+	label := widget.NewLabel(message)
+	label.Wrapping = fyne.TextWrapWord
+
+	closeDB := func() {
+		if db != nil {
+			db.Close()
+		}
+	}
+	retryBtn := widget.NewButton("Retry", func() {
+		closeDB()
+		startDatabase(w, path, ready)
+	})
+
+	// continuing is only offered for a readable database with problems
+	continueBtn := widget.NewButton("Continue anyway", func() {
+		if err := prepareSchema(db); err != nil {
+			showError("preparing database", err)
+			return
+		}
+		slog.Warn("continuing with damaged database", "path", path)
+		ready(db)
+	})
+	rebuildBtn := widget.NewButton("Rebuild from readable data", func() {
+		aside, err := rebuildDatabase(db, path, time.Now())
+		if err != nil {
+			showError("rebuilding database", err)
+			return
+		}
+		slog.Info("database rebuilt", "path", path, "damaged", aside)
+		startDatabase(w, path, ready)
+	})
+	if err != nil {
+		continueBtn.Hide()
+		rebuildBtn.Hide()
+	}
+
+	newBtn := widget.NewButton("Start new database", func() {
+		dialog.ShowConfirm("Start new database", "The damaged file is kept next to the new database. Continue?", func(ok bool) {
+			if !ok {
+				return
+			}
+			closeDB()
+			aside, err := moveAside(path, time.Now())
+			if err != nil {
+				showError("moving damaged database", err)
+				return
+			}
+			slog.Info("damaged database moved aside", "path", path, "damaged", aside)
+			startDatabase(w, path, ready)
+		}, w)
+	})
+	quitBtn := widget.NewButton("Quit", func() {
+		closeDB()
+		fyne.CurrentApp().Quit()
+	})
+
+	w.SetContent(container.NewVBox(
+		widget.NewLabelWithStyle("Database problem", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		label,
+		container.NewHBox(retryBtn, continueBtn, rebuildBtn, newBtn, quitBtn),
+	))
+}
@@ -0,0 +1,460 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// report periods and groupings
+const (
+	periodWeek  = "Week"
+	periodMonth = "Month"
+
+	groupByTitle   = "Title"
+	groupByProject = "Project"
+)
+
+// Timesheet is a per-day table of billed hours for one week or month,
+// with one column per title or project.
+type Timesheet struct {
+	Name    string
+	From    time.Time // inclusive, midnight
+	To      time.Time // exclusive, midnight
+	GroupBy string
+	Columns []string
+	Rows    []TimesheetRow
+	Totals  []time.Duration // per column
+	Total   time.Duration
+}
+
+// TimesheetRow is either a day or, in monthly timesheets, a week subtotal.
+type TimesheetRow struct {
+	Label    string
+	Date     time.Time
+	Subtotal bool
+	Cells    []time.Duration // per column
+	Total    time.Duration
+}
+
+// periodRange returns the week or month containing day, in day's location.
+func periodRange(period string, day time.Time, weekStart time.Weekday) (time.Time, time.Time) {
+	midnight := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location())
+	if period == periodMonth {
+		from := time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, day.Location())
+		return from, from.AddDate(0, 1, 0)
+	}
+	back := (int(midnight.Weekday()) - int(weekStart) + 7) % 7
+	from := midnight.AddDate(0, 0, -back)
+	return from, from.AddDate(0, 0, 7)
+}
+
+// buildTimesheet groups the billed durations of sessions in the period
+// containing day by date and by title or project. mode decides which sessions
+// count: contained ones on their start day, overlapping ones on their start
+// day or the first day of the period, clipped ones split over the days they
+// cover with prorated billed time.
+func buildTimesheet(sessions []Session, period string, day time.Time, groupBy string, weekStart time.Weekday, mode RangeMode) Timesheet {
+	from, to := periodRange(period, day, weekStart)
+	ts := Timesheet{From: from, To: to, GroupBy: groupBy}
+	if period == periodMonth {
+		ts.Name = "Timesheet " + from.Format("January 2006")
+	} else {
+		ts.Name = "Timesheet week " + from.Format("2006-01-02") + " to " + to.AddDate(0, 0, -1).Format("2006-01-02")
+	}
+
+	// collect hours per day and column
+	perDay := map[string]map[string]time.Duration{}
+	columns := map[string]bool{}
+	add := func(date time.Time, key string, d time.Duration) {
+		k := date.Format("2006-01-02")
+		if perDay[k] == nil {
+			perDay[k] = map[string]time.Duration{}
+		}
+		perDay[k][key] += d
+		columns[key] = true
+	}
+	for _, s := range sessions {
+		key := s.Title
+		if groupBy == groupByProject {
+			key = projectLabel(s.Project)
+		}
+		start := time.Unix(s.startUnix, 0).In(from.Location())
+		end := time.Unix(s.endUnix, 0).In(from.Location())
+
+		switch mode {
+		case rangeClipped:
+			for d := from; d.Before(to); d = d.AddDate(0, 0, 1) {
+				if part := clipSession(s, d, d.AddDate(0, 0, 1)); part.Difference > 0 {
+					add(d, key, part.Billed())
+				}
+			}
+		case rangeOverlapping:
+			if !start.Before(to) || !end.After(from) {
+				continue
+			}
+			if start.Before(from) {
+				start = from
+			}
+			add(start, key, s.Billed())
+		default:
+			if start.Before(from) || end.After(to) {
+				continue
+			}
+			add(start, key, s.Billed())
+		}
+	}
+	for c := range columns {
+		ts.Columns = append(ts.Columns, c)
+	}
+	sort.Strings(ts.Columns)
+	ts.Totals = make([]time.Duration, len(ts.Columns))
+
+	// one row per day, plus week subtotals in monthly sheets
+	week := TimesheetRow{Subtotal: true, Cells: make([]time.Duration, len(ts.Columns))}
+	weekFrom := from
+	for d := from; d.Before(to); d = d.AddDate(0, 0, 1) {
+		row := TimesheetRow{Label: d.Format("Mon 2006-01-02"), Date: d, Cells: make([]time.Duration, len(ts.Columns))}
+		for i, c := range ts.Columns {
+			v := perDay[d.Format("2006-01-02")][c]
+			row.Cells[i] = v
+			row.Total += v
+			week.Cells[i] += v
+			ts.Totals[i] += v
+		}
+		week.Total += row.Total
+		ts.Total += row.Total
+		ts.Rows = append(ts.Rows, row)
+
+		next := d.AddDate(0, 0, 1)
+		if period == periodMonth && (next.Weekday() == weekStart || !next.Before(to)) {
+			week.Label = "Week " + weekFrom.Format("01-02") + " to " + d.Format("01-02")
+			week.Date = weekFrom
+			ts.Rows = append(ts.Rows, week)
+			week = TimesheetRow{Subtotal: true, Cells: make([]time.Duration, len(ts.Columns))}
+			weekFrom = next
+		}
+	}
+	return ts
+}
+
+// hours formats a duration as decimal hours like "7.50".
+func hours(d time.Duration) string {
+	return strconv.FormatFloat(d.Hours(), 'f', 2, 64)
+}
+
+// writeTimesheetCSV writes the timesheet with decimal hours.
+func writeTimesheetCSV(w io.Writer, ts Timesheet, delimiter rune) error {
+	_, _ = w.Write([]byte{0xEF, 0xBB, 0xBF})
+	writer := csv.NewWriter(w)
+	writer.Comma = delimiter
+
+	header := append(append([]string{"Date"}, ts.Columns...), "Total")
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	for _, r := range ts.Rows {
+		record := []string{r.Label}
+		for _, c := range r.Cells {
+			record = append(record, hours(c))
+		}
+		record = append(record, hours(r.Total))
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	total := []string{"Total"}
+	for _, c := range ts.Totals {
+		total = append(total, hours(c))
+	}
+	total = append(total, hours(ts.Total))
+	if err := writer.Write(total); err != nil {
+		return err
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// writeTimesheetXLSX writes a styled workbook. Day rows hold hours as numbers,
+// row totals use SUM and subtotals/grand totals use SUBTOTAL so that the
+// grand total ignores the week subtotal rows.
+func writeTimesheetXLSX(w io.Writer, ts Timesheet) error {
+	f := excelize.NewFile()
+	defer f.Close()
+	sheet := "Timesheet"
+	if err := f.SetSheetName("Sheet1", sheet); err != nil {
+		return err
+	}
+
+	hoursFmt := "0.00"
+	dateFmt := "ddd yyyy-mm-dd"
+	titleStyle, _ := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true, Size: 14}})
+	headerStyle, _ := f.NewStyle(&excelize.Style{
+		Font:   &excelize.Font{Bold: true},
+		Fill:   excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"#DDEBF7"}},
+		Border: []excelize.Border{{Type: "bottom", Color: "#000000", Style: 1}},
+	})
+	dateStyle, _ := f.NewStyle(&excelize.Style{CustomNumFmt: &dateFmt})
+	hoursStyle, _ := f.NewStyle(&excelize.Style{CustomNumFmt: &hoursFmt})
+	subtotalStyle, _ := f.NewStyle(&excelize.Style{
+		Font:         &excelize.Font{Bold: true},
+		Fill:         excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"#F2F2F2"}},
+		CustomNumFmt: &hoursFmt,
+	})
+	totalStyle, _ := f.NewStyle(&excelize.Style{
+		Font:         &excelize.Font{Bold: true},
+		Border:       []excelize.Border{{Type: "top", Color: "#000000", Style: 6}},
+		CustomNumFmt: &hoursFmt,
+	})
+
+	lastCol := len(ts.Columns) + 2
+
+	f.SetCellValue(sheet, "A1", ts.Name)
+	f.SetCellStyle(sheet, "A1", "A1", titleStyle)
+	f.SetCellValue(sheet, "A2", "Billed hours by "+ts.GroupBy)
+
+	headerRow := 4
+	f.SetCellValue(sheet, cellName(1, headerRow), "Date")
+	for i, c := range ts.Columns {
+		f.SetCellValue(sheet, cellName(i+2, headerRow), c)
+	}
+	f.SetCellValue(sheet, cellName(lastCol, headerRow), "Total")
+	f.SetCellStyle(sheet, cellName(1, headerRow), cellName(lastCol, headerRow), headerStyle)
+
+	firstData := headerRow + 1
+	row := firstData
+	weekFirst := firstData
+	for _, r := range ts.Rows {
+		if r.Subtotal {
+			f.SetCellValue(sheet, cellName(1, row), r.Label)
+			for col := 2; col <= lastCol; col++ {
+				f.SetCellFormula(sheet, cellName(col, row), fmt.Sprintf("SUBTOTAL(9,%s:%s)", cellName(col, weekFirst), cellName(col, row-1)))
+			}
+			f.SetCellStyle(sheet, cellName(1, row), cellName(lastCol, row), subtotalStyle)
+			row++
+			weekFirst = row
+			continue
+		}
+		f.SetCellValue(sheet, cellName(1, row), r.Date)
+		f.SetCellStyle(sheet, cellName(1, row), cellName(1, row), dateStyle)
+		for i, c := range r.Cells {
+			if c > 0 {
+				f.SetCellFloat(sheet, cellName(i+2, row), c.Hours(), 4, 64)
+			}
+		}
+		// a period without sessions has no columns to sum
+		if len(ts.Columns) == 0 {
+			f.SetCellInt(sheet, cellName(lastCol, row), 0)
+		} else {
+			f.SetCellFormula(sheet, cellName(lastCol, row), fmt.Sprintf("SUM(%s:%s)", cellName(2, row), cellName(lastCol-1, row)))
+		}
+		f.SetCellStyle(sheet, cellName(2, row), cellName(lastCol, row), hoursStyle)
+		row++
+	}
+
+	f.SetCellValue(sheet, cellName(1, row), "Total")
+	for col := 2; col <= lastCol; col++ {
+		f.SetCellFormula(sheet, cellName(col, row), fmt.Sprintf("SUBTOTAL(9,%s:%s)", cellName(col, firstData), cellName(col, row-1)))
+	}
+	f.SetCellStyle(sheet, cellName(1, row), cellName(lastCol, row), totalStyle)
+
+	colName, _ := excelize.ColumnNumberToName(lastCol)
+	f.SetColWidth(sheet, "A", "A", 22)
+	f.SetColWidth(sheet, "B", colName, 14)
+	f.SetPanes(sheet, &excelize.Panes{Freeze: true, XSplit: 1, YSplit: headerRow, TopLeftCell: cellName(2, firstData), ActivePane: "bottomRight"})
+
+	_, err := f.WriteTo(w)
+	return err
+}
+
+// timesheetHTML is a printable, self-contained timesheet page.
+var timesheetHTML = template.Must(template.New("timesheet").Funcs(template.FuncMap{"hours": hours}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Name}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; width: 100%; }
+th, td { border: 1px solid #999; padding: 4px 8px; }
+th { background: #ddebf7; text-align: left; }
+td.num { text-align: right; }
+tr.subtotal td { background: #f2f2f2; font-weight: bold; }
+tr.total td { font-weight: bold; border-top: 3px double #000; }
+@media print { body { margin: 0; } th { -webkit-print-color-adjust: exact; print-color-adjust: exact; } }
+</style>
+</head>
+<body>
+<h1>{{.Name}}</h1>
+<p>Billed hours by {{.GroupBy}}</p>
+<table>
+<tr><th>Date</th>{{range .Columns}}<th>{{.}}</th>{{end}}<th>Total</th></tr>
+{{range .Rows}}<tr{{if .Subtotal}} class="subtotal"{{end}}><td>{{.Label}}</td>{{range .Cells}}<td class="num">{{hours .}}</td>{{end}}<td class="num">{{hours .Total}}</td></tr>
+{{end}}<tr class="total"><td>Total</td>{{range .Totals}}<td class="num">{{hours .}}</td>{{end}}<td class="num">{{hours .Total}}</td></tr>
+</table>
+</body>
+</html>
+`))
+
+// writeTimesheetHTML writes the timesheet as a printable HTML page.
+func writeTimesheetHTML(w io.Writer, ts Timesheet) error {
+	return timesheetHTML.Execute(w, ts)
+}
+
+// projectLabel names the group of sessions without a project.
+func projectLabel(project string) string {
+	if project == "" {
+		return "(no project)"
+	}
+	return project
+}
+
+// showExportDialog asks for a file name and passes the opened writer to write.
+// write returns a status message or an error.
+func showExportDialog(cfg *Config, filename string, statusLabel *widget.Label, write func(w fyne.URIWriteCloser) (string, error)) {
+	parent := fyne.CurrentApp().Driver().AllWindows()[0]
+	fd := dialog.NewFileSave(func(w fyne.URIWriteCloser, err error) {
+		if w == nil {
+			return // user cancelled
+		}
+		defer w.Close()
+
+		msg, err := write(w)
+		if err != nil {
+			statusLabel.SetText("Error writing " + w.URI().Name() + ": " + err.Error())
+			return
+		}
+		statusLabel.SetText(msg)
+	}, parent)
+	fd.SetFileName(filename)
+	if dir := exportLocation(cfg); dir != nil {
+		fd.SetLocation(dir)
+	}
+	fd.Show()
+}
+
+// createReportsTab builds weekly and monthly timesheets and exports them.
+func createReportsTab(store SessionStore, cfg *Config) fyne.CanvasObject {
+	statusLabel := widget.NewLabel("")
+	periodSelect := widget.NewSelect([]string{periodWeek, periodMonth}, nil)
+	periodSelect.SetSelected(periodWeek)
+	groupSelect := widget.NewSelect([]string{groupByTitle, groupByProject}, nil)
+	groupSelect.SetSelected(groupByTitle)
+	dayEntry := newTimeEntry("Any day in the period")
+	dayEntry.SetText("today")
+	preview := widget.NewLabel("")
+
+	rangeModeSelect := newRangeModeSelect()
+
+	// build reads the inputs and creates the timesheet
+	build := func() (Timesheet, error) {
+		day, err := parseTimeInput(dayEntry.Text, time.Now(), time.Local)
+		if err != nil {
+			return Timesheet{}, fmt.Errorf("invalid day: %w", err)
+		}
+		from, to := periodRange(periodSelect.Selected, day, cfg.weekStartDay())
+		sessions, err := store.List(SessionFilter{From: from, To: to, Mode: rangeOverlapping})
+		if err != nil {
+			return Timesheet{}, fmt.Errorf("error reading sessions: %w", err)
+		}
+		return buildTimesheet(sessions, periodSelect.Selected, day, groupSelect.Selected, cfg.weekStartDay(), RangeMode(rangeModeSelect.Selected)), nil
+	}
+
+	previewBtn := widget.NewButton("Preview", func() {
+		ts, err := build()
+		if err != nil {
+			statusLabel.SetText(err.Error())
+			return
+		}
+		text := ts.Name + "\n"
+		for i, c := range ts.Columns {
+			text += fmt.Sprintf("%s: %s h\n", c, hours(ts.Totals[i]))
+		}
+		text += "Total: " + hours(ts.Total) + " h"
+		preview.SetText(text)
+		statusLabel.SetText("")
+	})
+
+	// export runs one of the timesheet writers through the save dialog
+	export := func(ext string, write func(io.Writer, Timesheet) error) func() {
+		return func() {
+			ts, err := build()
+			if err != nil {
+				statusLabel.SetText(err.Error())
+				return
+			}
+			filename := "timesheet_" + ts.From.Format("2006-01-02") + "." + ext
+			showExportDialog(cfg, filename, statusLabel, func(w fyne.URIWriteCloser) (string, error) {
+				if err := write(w, ts); err != nil {
+					return "", err
+				}
+				return "Exported " + ts.Name + " to " + w.URI().Name(), nil
+			})
+		}
+	}
+
+	xlsxBtn := widget.NewButton("Export timesheet to XLSX", export("xlsx", writeTimesheetXLSX))
+	csvBtn := widget.NewButton("Export timesheet to CSV", export("csv", func(w io.Writer, ts Timesheet) error {
+		return writeTimesheetCSV(w, ts, cfg.csvDelimiter())
+	}))
+	htmlBtn := widget.NewButton("Export timesheet to HTML", export("html", writeTimesheetHTML))
+
+	// range reports from templates
+	rangeStart := newTimeEntry("Range start (empty: all sessions)")
+	rangeEnd := newTimeEntry("Range end (empty: now)")
+	exportReport := func(ext string, write func(io.Writer, ReportData) error) func() {
+		return func() {
+			from, to, _, err := parseOptionalRange(rangeStart.Text, rangeEnd.Text, time.Now(), time.Local)
+			if err != nil {
+				statusLabel.SetText("Invalid time range: " + err.Error())
+				return
+			}
+			sessions, err := store.List(SessionFilter{From: from, To: to, Mode: RangeMode(rangeModeSelect.Selected)})
+			if err != nil {
+				showError("reading sessions", err)
+				return
+			}
+			data := newReportData(sessions, from, to)
+			filename := "report_" + time.Now().Format("2006-01-02_15-04-05") + "." + ext
+			showExportDialog(cfg, filename, statusLabel, func(w fyne.URIWriteCloser) (string, error) {
+				if err := write(w, data); err != nil {
+					return "", err
+				}
+				return fmt.Sprintf("Exported report of %d sessions to %s", len(sessions), w.URI().Name()), nil
+			})
+		}
+	}
+	markdownBtn := widget.NewButton("Export report to Markdown", exportReport("md", writeMarkdownReport))
+	htmlReportBtn := widget.NewButton("Export report to HTML", exportReport("html", writeHTMLReport))
+
+	return container.NewVScroll(container.NewVBox(
+		statusLabel,
+		container.NewHBox(widget.NewLabel("Sessions on the range edges:"), rangeModeSelect),
+		widget.NewSeparator(),
+		widget.NewLabel("Timesheet"),
+		container.NewHBox(widget.NewLabel("Period:"), periodSelect, widget.NewLabel("Group by:"), groupSelect),
+		dayEntry,
+		previewBtn,
+		xlsxBtn,
+		csvBtn,
+		htmlBtn,
+		preview,
+		widget.NewSeparator(),
+		widget.NewLabel("Report by day (templates in "+templateDir()+" override the layout)"),
+		rangeStart,
+		rangeEnd,
+		markdownBtn,
+		htmlReportBtn,
+	))
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimesheetXLSXEmptyPeriod(t *testing.T) {
+	for _, period := range []string{periodWeek, periodMonth} {
+		t.Run(period, func(t *testing.T) {
+			ts := buildTimesheet(nil, period, testTime(12, 0), groupByProject, time.Monday, rangeContained)
+			if len(ts.Columns) != 0 || len(ts.Rows) == 0 {
+				t.Fatalf("empty period has %d columns and %d rows", len(ts.Columns), len(ts.Rows))
+			}
+			var buf bytes.Buffer
+			if err := writeTimesheetXLSX(&buf, ts); err != nil {
+				t.Fatal(err)
+			}
+			cells := xlsxCells(t, buf.Bytes())
+			// the totals in column B must not reach back into the date column
+			if bytes.Contains(cells, []byte(":A")) {
+				t.Errorf("formula refers to the date column:\n%s", cells)
+			}
+			compareGolden(t, "timesheet_xlsx_empty_"+strings.ToLower(period)+".golden", cells)
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// rounding modes for billed durations
+const (
+	roundingInherit = "inherit" // projects only: use the global policy
+	roundingNone    = "none"
+	roundingUp      = "up"
+	roundingDown    = "down"
+	roundingNearest = "nearest"
+)
+
+// roundingModes lists the modes offered in the UI (inherit is added for projects).
+var roundingModes = []string{roundingNone, roundingUp, roundingDown, roundingNearest}
+
+// RoundingPolicy describes how a raw duration is turned into a billed duration.
+// Minutes is the increment used by the up/down/nearest modes and MinimumMinutes
+// is the smallest billable duration for any non-empty session.
+type RoundingPolicy struct {
+	Mode           string `json:"mode"`
+	Minutes        int    `json:"minutes"`
+	MinimumMinutes int    `json:"minimum_minutes"`
+}
+
+// Apply returns the billed duration for the raw duration d.
+func (p RoundingPolicy) Apply(d time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
+	}
+
+	billed := d
+	step := time.Duration(p.Minutes) * time.Minute
+	if step > 0 {
+		switch p.Mode {
+		case roundingUp:
+			billed = ((d + step - 1) / step) * step
+		case roundingDown:
+			billed = (d / step) * step
+		case roundingNearest:
+			billed = ((d + step/2) / step) * step
+		}
+	}
+
+	minimum := time.Duration(p.MinimumMinutes) * time.Minute
+	if billed < minimum {
+		billed = minimum
+	}
+	return billed
+}
+
+// String returns a short human readable description like "up to 15 min, min 30 min".
+func (p RoundingPolicy) String() string {
+	var s string
+	switch p.Mode {
+	case roundingInherit:
+		return "global rounding"
+	case roundingUp, roundingDown, roundingNearest:
+		if p.Minutes > 0 {
+			s = fmt.Sprintf("%s to %d min", p.Mode, p.Minutes)
+		} else {
+			s = "no rounding"
+		}
+	default:
+		s = "no rounding"
+	}
+	if p.MinimumMinutes > 0 {
+		s += fmt.Sprintf(", min %d min", p.MinimumMinutes)
+	}
+	return s
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// showUnlock asks for the passphrase of an encrypted database before ready is
+// called with the unlocked cipher. Unencrypted databases are ready right away.
+func showUnlock(w fyne.Window, db *sql.DB, path string, ready func(fc *fieldCipher)) {
+	fc := &fieldCipher{}
+	encrypted, err := isEncrypted(db)
+	if err == nil && !encrypted {
+		ready(fc)
+		return
+	}
+
+	statusLabel := widget.NewLabel("The database " + path + " is encrypted.")
+	statusLabel.Wrapping = fyne.TextWrapWord
+	passEntry := widget.NewPasswordEntry()
+	passEntry.SetPlaceHolder("Passphrase")
+
+	var unlockBtn *widget.Button
+	unlock := func() {
+		key, err := unlockDatabase(db, passEntry.Text)
+		if errors.Is(err, errWrongPassphrase) {
+			slog.Warn("wrong passphrase", "path", path)
+			statusLabel.SetText("Wrong passphrase, try again.")
+			passEntry.SetText("")
+			return
+		}
+		if err == nil {
+			err = fc.setKey(key)
+		}
+		if err != nil {
+			showError("unlocking database", err)
+			return
+		}
+		slog.Info("database unlocked", "path", path)
+		ready(fc)
+	}
+	passEntry.OnSubmitted = func(string) { unlock() }
+	unlockBtn = widget.NewButton("Unlock", unlock)
+	quitBtn := widget.NewButton("Quit", func() { fyne.CurrentApp().Quit() })
+
+	if err != nil {
+		statusLabel.SetText("Error reading the encryption key: " + err.Error())
+		passEntry.Disable()
+		unlockBtn.Disable()
+	}
+
+	w.SetContent(container.NewVBox(
+		widget.NewLabelWithStyle("Unlock workspace", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		statusLabel,
+		passEntry,
+		container.NewHBox(unlockBtn, quitBtn),
+	))
+	w.Canvas().Focus(passEntry)
+}
+
+// createSecurityTab enables encryption, changes the passphrase and writes or
+// restores encrypted backups. A restored backup becomes a new workspace that
+// is opened with openWorkspace.
+func createSecurityTab(db *sql.DB, fc *fieldCipher, cfg *Config, openWorkspace func(name string)) fyne.CanvasObject {
+	statusLabel := widget.NewLabel("")
+	parent := fyne.CurrentApp().Driver().AllWindows()[0]
+
+	// encryption: enabling shows the passphrase change instead
+	encryptionLabel := widget.NewLabel("")
+	newPass := widget.NewPasswordEntry()
+	newPass.SetPlaceHolder(fmt.Sprintf("New passphrase (at least %d characters)", minPassphraseLength))
+	confirmPass := widget.NewPasswordEntry()
+	confirmPass.SetPlaceHolder("Repeat the new passphrase")
+	oldPass := widget.NewPasswordEntry()
+	oldPass.SetPlaceHolder("Current passphrase")
+
+	var enableBtn, changeBtn *widget.Button
+	showState := func() {
+		newPass.SetText("")
+		confirmPass.SetText("")
+		oldPass.SetText("")
+		if fc.enabled() {
+			encryptionLabel.SetText("Titles and descriptions are encrypted. There is no way to read them without the passphrase.")
+			enableBtn.Hide()
+			oldPass.Show()
+			changeBtn.Show()
+		} else {
+			encryptionLabel.SetText("Titles and descriptions are stored unencrypted. Encrypting them needs a passphrase on every start; a lost passphrase cannot be recovered.")
+			enableBtn.Show()
+			oldPass.Hide()
+			changeBtn.Hide()
+		}
+	}
+
+	// newPassphrase returns the new passphrase if both entries match
+	newPassphrase := func() (string, bool) {
+		if newPass.Text != confirmPass.Text {
+			statusLabel.SetText("The passphrases do not match")
+			return "", false
+		}
+		return newPass.Text, true
+	}
+
+	enableBtn = widget.NewButton("Encrypt this workspace", func() {
+		passphrase, ok := newPassphrase()
+		if !ok {
+			return
+		}
+		dialog.ShowConfirm("Encrypt workspace", "Encrypt all sessions of this workspace? Without the passphrase they cannot be read anymore.", func(ok bool) {
+			if !ok {
+				return
+			}
+			key, err := enableEncryption(db, passphrase)
+			if key != nil {
+				if err := fc.setKey(key); err != nil {
+					showError("enabling encryption", err)
+					return
+				}
+				slog.Info("encryption enabled")
+			}
+			if err != nil {
+				showError("enabling encryption", err)
+			} else {
+				statusLabel.SetText("Workspace encrypted")
+			}
+			showState()
+		}, parent)
+	})
+
+	changeBtn = widget.NewButton("Change passphrase", func() {
+		passphrase, ok := newPassphrase()
+		if !ok {
+			return
+		}
+		err := changePassphrase(db, oldPass.Text, passphrase)
+		if errors.Is(err, errWrongPassphrase) {
+			statusLabel.SetText("The current passphrase is wrong")
+			return
+		}
+		if err != nil {
+			showError("changing passphrase", err)
+			return
+		}
+		slog.Info("passphrase changed")
+		statusLabel.SetText("Passphrase changed")
+		showState()
+	})
+	showState()
+
+	// backups are encrypted with their own passphrase
+	backupPass := widget.NewPasswordEntry()
+	backupPass.SetPlaceHolder("Backup passphrase")
+	backupConfirm := widget.NewPasswordEntry()
+	backupConfirm.SetPlaceHolder("Repeat the backup passphrase")
+	backupBtn := widget.NewButton("Write encrypted backup...", func() {
+		if backupPass.Text != backupConfirm.Text {
+			statusLabel.SetText("The backup passphrases do not match")
+			return
+		}
+		if len(backupPass.Text) < minPassphraseLength {
+			statusLabel.SetText(fmt.Sprintf("The backup passphrase needs at least %d characters", minPassphraseLength))
+			return
+		}
+		name := "taskTracker-" + time.Now().Format("2006-01-02") + "." + backupExtension
+		showExportDialog(cfg, name, statusLabel, func(w fyne.URIWriteCloser) (string, error) {
+			if err := writeEncryptedBackup(db, w, backupPass.Text); err != nil {
+				return "", err
+			}
+			slog.Info("backup written", "file", w.URI().Path())
+			backupPass.SetText("")
+			backupConfirm.SetText("")
+			return "Backup written to " + w.URI().Name(), nil
+		})
+	})
+
+	// restoring writes a new workspace and never touches an existing database
+	restoreName := widget.NewEntry()
+	restoreName.SetPlaceHolder("Name of the restored workspace")
+	restorePass := widget.NewPasswordEntry()
+	restorePass.SetPlaceHolder("Backup passphrase")
+	restoreBtn := widget.NewButton("Restore backup as workspace...", func() {
+		name := strings.TrimSpace(restoreName.Text)
+		if err := cfg.checkWorkspaceName(name); err != nil {
+			statusLabel.SetText(err.Error())
+			return
+		}
+		fd := dialog.NewFileOpen(func(r fyne.URIReadCloser, err error) {
+			if r == nil {
+				return
+			}
+			defer r.Close()
+
+			path := workspaceFile(name)
+			err = restoreEncryptedBackup(r, restorePass.Text, path)
+			if errors.Is(err, errWrongPassphrase) {
+				statusLabel.SetText("Wrong backup passphrase")
+				return
+			}
+			if err != nil {
+				showError("restoring backup", err)
+				return
+			}
+			if err := cfg.addWorkspace(Workspace{Name: name}); err != nil {
+				showError("adding workspace", err)
+				return
+			}
+			if err := cfg.save(); err != nil {
+				showError("saving settings", err)
+				return
+			}
+			slog.Info("backup restored", "file", r.URI().Path(), "workspace", name)
+			restoreName.SetText("")
+			restorePass.SetText("")
+			openWorkspace(name)
+		}, parent)
+		fd.Show()
+	})
+
+	return container.NewVScroll(container.NewVBox(
+		statusLabel,
+		widget.NewLabel("Encryption"),
+		encryptionLabel,
+		oldPass,
+		newPass,
+		confirmPass,
+		container.NewHBox(enableBtn, changeBtn),
+		widget.NewSeparator(),
+		widget.NewLabel("Backup"),
+		backupPass,
+		backupConfirm,
+		backupBtn,
+		widget.NewSeparator(),
+		widget.NewLabel("Restore"),
+		restoreName,
+		restorePass,
+		restoreBtn,
+	))
+}
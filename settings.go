@@ -0,0 +1,289 @@
+package main
+
+import (
+	"image/color"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// theme names stored in the config
+const (
+	themeSystem = "system"
+	themeLight  = "light"
+	themeDark   = "dark"
+)
+
+// weekdays offered as week start
+var weekStartDays = []string{"monday", "sunday", "saturday"}
+
+// variantTheme wraps the default theme but always uses one variant (light or dark).
+type variantTheme struct {
+	fyne.Theme
+	variant fyne.ThemeVariant
+}
+
+// Color returns the default theme color for the forced variant.
+func (t variantTheme) Color(name fyne.ThemeColorName, _ fyne.ThemeVariant) color.Color {
+	return t.Theme.Color(name, t.variant)
+}
+
+// applySettings applies settings that take effect without a restart.
+func applySettings(a fyne.App, cfg *Config) {
+	switch cfg.Theme {
+	case themeLight:
+		a.Settings().SetTheme(variantTheme{Theme: theme.DefaultTheme(), variant: theme.VariantLight})
+	case themeDark:
+		a.Settings().SetTheme(variantTheme{Theme: theme.DefaultTheme(), variant: theme.VariantDark})
+	default:
+		a.Settings().SetTheme(theme.DefaultTheme())
+	}
+
+	if cfg.Locale != "" {
+		moneyLocale = cfg.Locale
+	} else {
+		moneyLocale = systemLocale
+	}
+}
+
+// exportLocation returns the configured export directory for file dialogs, or nil.
+func exportLocation(cfg *Config) fyne.ListableURI {
+	if cfg.Export.Directory == "" {
+		return nil
+	}
+	dir, err := storage.ListerForURI(storage.NewFileURI(cfg.Export.Directory))
+	if err != nil {
+		return nil
+	}
+	return dir
+}
+
+// createSettingsTab edits the config file. Most settings apply immediately,
+// the database location of the default workspace when it is opened next.
+func createSettingsTab(cfg *Config) fyne.CanvasObject {
+	statusLabel := widget.NewLabel("")
+
+	// database location
+	dbPathEntry := widget.NewEntry()
+	dbPathEntry.SetPlaceHolder(defaultDBPath())
+	dbPathEntry.SetText(cfg.DBPath)
+	browseDBBtn := widget.NewButton("Browse...", func() {
+		parent := fyne.CurrentApp().Driver().AllWindows()[0]
+		fd := dialog.NewFolderOpen(func(dir fyne.ListableURI, err error) {
+			if dir == nil {
+				return
+			}
+			dbPathEntry.SetText(filepath.Join(dir.Path(), "taskTracker.db"))
+		}, parent)
+		fd.Show()
+	})
+
+	// billing defaults
+	defaultRateEntry := widget.NewEntry()
+	defaultRateEntry.SetPlaceHolder("Default hourly rate (optional)")
+	defaultRateEntry.SetText(cfg.defaultRateText())
+	currencySelect := widget.NewSelect(currencyCodes, nil)
+	currencySelect.SetSelected(cfg.Currency)
+	roundingForm, getRounding, setRounding := newRoundingForm(roundingModes)
+	setRounding(cfg.Rounding)
+
+	// formatting
+	localeEntry := widget.NewEntry()
+	localeEntry.SetPlaceHolder("Locale for amounts, e.g. de-CH (empty: system " + systemLocale + ")")
+	localeEntry.SetText(cfg.Locale)
+	weekStartSelect := widget.NewSelect(weekStartDays, nil)
+	weekStartSelect.SetSelected(cfg.WeekStart)
+
+	// export defaults
+	delimiterEntry := widget.NewEntry()
+	delimiterEntry.SetPlaceHolder("CSV delimiter (default ;)")
+	delimiterEntry.SetText(cfg.Export.CSVDelimiter)
+	exportDirEntry := widget.NewEntry()
+	exportDirEntry.SetPlaceHolder("Default export folder (optional)")
+	exportDirEntry.SetText(cfg.Export.Directory)
+
+	themeSelect := widget.NewSelect([]string{themeSystem, themeLight, themeDark}, nil)
+	themeSelect.SetSelected(cfg.Theme)
+
+	// git repositories for commit correlation, one path per line
+	gitReposEntry := widget.NewMultiLineEntry()
+	gitReposEntry.SetPlaceHolder("Local git repositories, one path per line")
+	gitReposEntry.SetText(strings.Join(cfg.GitRepos, "\n"))
+	gitAuthorEntry := widget.NewEntry()
+	gitAuthorEntry.SetPlaceHolder("Commit author (empty: user.email of each repository)")
+	gitAuthorEntry.SetText(cfg.GitAuthor)
+
+	// working time target
+	targetEntry := widget.NewEntry()
+	targetEntry.SetPlaceHolder("Weekly target hours, e.g. 40 (empty: none)")
+	if cfg.WeeklyTargetMinutes > 0 {
+		targetEntry.SetText(hours(time.Duration(cfg.WeeklyTargetMinutes) * time.Minute))
+	}
+	workDaysCheck := widget.NewCheckGroup(allWeekdays, nil)
+	workDaysCheck.Horizontal = true
+	if len(cfg.WorkDays) > 0 {
+		workDaysCheck.SetSelected(cfg.WorkDays)
+	} else {
+		workDaysCheck.SetSelected(defaultWorkDays)
+	}
+
+	// identity and team folder for team reports
+	userNameEntry := widget.NewEntry()
+	userNameEntry.SetPlaceHolder("Your name")
+	userNameEntry.SetText(cfg.UserName)
+	userEmailEntry := widget.NewEntry()
+	userEmailEntry.SetPlaceHolder("Your email")
+	userEmailEntry.SetText(cfg.UserEmail)
+	teamFolderEntry := widget.NewEntry()
+	teamFolderEntry.SetPlaceHolder("Team folder shared with the others (optional)")
+	teamFolderEntry.SetText(cfg.TeamFolder)
+	browseTeamBtn := widget.NewButton("Browse...", func() {
+		parent := fyne.CurrentApp().Driver().AllWindows()[0]
+		fd := dialog.NewFolderOpen(func(dir fyne.ListableURI, err error) {
+			if dir == nil {
+				return
+			}
+			teamFolderEntry.SetText(dir.Path())
+		}, parent)
+		fd.Show()
+	})
+
+	metricsAddrEntry := widget.NewEntry()
+	metricsAddrEntry.SetPlaceHolder("Address for /metrics, e.g. 127.0.0.1:9464 (empty: off)")
+	metricsAddrEntry.SetText(cfg.MetricsAddr)
+	metricsTitlesCheck := widget.NewCheck("Label metrics with session titles (never for encrypted workspaces)", nil)
+	metricsTitlesCheck.SetChecked(cfg.MetricsTitles)
+
+	saveBtn := widget.NewButton("Save settings", func() {
+		var rate Money
+		if s := strings.TrimSpace(defaultRateEntry.Text); s != "" {
+			r, err := parseMoney(s)
+			if err != nil {
+				statusLabel.SetText("Invalid default rate!")
+				return
+			}
+			rate = r
+		}
+		rounding, err := getRounding()
+		if err != nil {
+			statusLabel.SetText("Invalid rounding: " + err.Error())
+			return
+		}
+		delimiter := delimiterEntry.Text
+		if len([]rune(delimiter)) > 1 {
+			statusLabel.SetText("CSV delimiter must be a single character")
+			return
+		}
+		targetMinutes, err := parseHours(targetEntry.Text)
+		if err != nil {
+			statusLabel.SetText(err.Error())
+			return
+		}
+		if len(workDaysCheck.Selected) == 0 {
+			statusLabel.SetText("Choose at least one work day")
+			return
+		}
+
+		// all input is valid; apply it, and take it back if saving fails, so
+		// the settings in use always match the file
+		previous := *cfg
+		restart := strings.TrimSpace(dbPathEntry.Text) != cfg.DBPath
+
+		cfg.DBPath = strings.TrimSpace(dbPathEntry.Text)
+		cfg.DefaultRate = rate
+		cfg.Currency = currencySelect.Selected
+		cfg.Rounding = rounding
+		cfg.Locale = strings.TrimSpace(localeEntry.Text)
+		cfg.WeekStart = weekStartSelect.Selected
+		cfg.Export.CSVDelimiter = delimiter
+		cfg.Export.Directory = strings.TrimSpace(exportDirEntry.Text)
+		cfg.Theme = themeSelect.Selected
+		cfg.GitRepos = nil
+		for _, line := range strings.Split(gitReposEntry.Text, "\n") {
+			if repo := strings.TrimSpace(line); repo != "" {
+				cfg.GitRepos = append(cfg.GitRepos, repo)
+			}
+		}
+		cfg.GitAuthor = strings.TrimSpace(gitAuthorEntry.Text)
+		cfg.WeeklyTargetMinutes = targetMinutes
+		cfg.WorkDays = nil
+		for _, day := range allWeekdays {
+			if slices.Contains(workDaysCheck.Selected, day) {
+				cfg.WorkDays = append(cfg.WorkDays, day)
+			}
+		}
+		cfg.UserName = strings.TrimSpace(userNameEntry.Text)
+		cfg.UserEmail = strings.TrimSpace(userEmailEntry.Text)
+		cfg.TeamFolder = strings.TrimSpace(teamFolderEntry.Text)
+		metricsAddr := strings.TrimSpace(metricsAddrEntry.Text)
+		restartMetrics := metricsAddr != cfg.MetricsAddr
+		cfg.MetricsAddr = metricsAddr
+		cfg.MetricsTitles = metricsTitlesCheck.Checked
+
+		if err := cfg.save(); err != nil {
+			*cfg = previous
+			statusLabel.SetText("Error saving settings: " + err.Error())
+			return
+		}
+		applySettings(fyne.CurrentApp(), cfg)
+
+		if restart {
+			statusLabel.SetText("Settings saved. The default workspace uses the new database location when it is opened next.")
+			return
+		}
+		if restartMetrics {
+			statusLabel.SetText("Settings saved. The metrics address is used after a restart.")
+			return
+		}
+		statusLabel.SetText("Settings saved")
+	})
+
+	return container.NewVScroll(container.NewVBox(
+		statusLabel,
+		widget.NewLabel("Database file of the default workspace (used when it is opened next)"),
+		container.NewBorder(nil, nil, nil, browseDBBtn, dbPathEntry),
+		widget.NewSeparator(),
+		widget.NewLabel("Billing"),
+		defaultRateEntry,
+		container.NewHBox(widget.NewLabel("Currency:"), currencySelect),
+		roundingForm,
+		widget.NewSeparator(),
+		widget.NewLabel("Formatting"),
+		localeEntry,
+		container.NewHBox(widget.NewLabel("Week starts on:"), weekStartSelect),
+		widget.NewSeparator(),
+		widget.NewLabel("Export"),
+		delimiterEntry,
+		exportDirEntry,
+		widget.NewSeparator(),
+		container.NewHBox(widget.NewLabel("Theme:"), themeSelect),
+		widget.NewSeparator(),
+		widget.NewLabel("Git commits"),
+		gitReposEntry,
+		gitAuthorEntry,
+		widget.NewSeparator(),
+		widget.NewLabel("Working time"),
+		targetEntry,
+		workDaysCheck,
+		widget.NewSeparator(),
+		widget.NewLabel("Team"),
+		userNameEntry,
+		userEmailEntry,
+		container.NewBorder(nil, nil, nil, browseTeamBtn, teamFolderEntry),
+		widget.NewSeparator(),
+		widget.NewLabel("Prometheus metrics (used after a restart)"),
+		metricsAddrEntry,
+		metricsTitlesCheck,
+		widget.NewSeparator(),
+		widget.NewLabel("Hooks: executables named on-start, on-stop or on-save in "+hookDir()+" run on these events"),
+		saveBtn,
+	))
+}
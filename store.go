@@ -0,0 +1,386 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// errSessionNotFound is returned by a SessionStore for an unknown session id.
+var errSessionNotFound = errors.New("session not found")
+
+// SessionStore keeps the work sessions. Tabs, exports and imports use it
+// instead of SQL, so they work the same on any implementation.
+type SessionStore interface {
+	// Create stores s and sets its ID. An empty uuid gets a new one, an empty
+	// zone the local zone and an empty currency the default currency.
+	Create(s *Session) error
+	// Get returns the session with the id or errSessionNotFound.
+	Get(id int) (Session, error)
+	// Update writes the fields of s to the session with s.ID. The uuid and
+	// created_by of a session never change.
+	Update(s Session) error
+	// Delete removes the session with the id or returns errSessionNotFound.
+	Delete(id int) error
+	// List returns the sessions matching filter, newest first; sessions ending
+	// at the same time are ordered by descending id.
+	List(filter SessionFilter) ([]Session, error)
+	// RecentTasks returns up to limit distinct recent tasks, newest first.
+	RecentTasks(limit int) ([]RecentTask, error)
+}
+
+// SessionFilter selects sessions in SessionStore.List. Zero fields match everything.
+type SessionFilter struct {
+	From, To time.Time // range, applied according to Mode
+	Mode     RangeMode // empty means rangeContained
+	Project  string
+	UUID     string
+}
+
+// overlapping reports whether the range selects overlapping sessions.
+func (f SessionFilter) overlapping() bool {
+	return f.Mode == rangeOverlapping || f.Mode == rangeClipped
+}
+
+// where returns the WHERE clause of the filter, empty when it matches
+// everything, and its arguments. It works on both session tables.
+func (f SessionFilter) where() (string, []any) {
+	var conds []string
+	var args []any
+	if !f.From.IsZero() {
+		if f.overlapping() {
+			conds = append(conds, "end_unix > ?")
+		} else {
+			conds = append(conds, "start_unix >= ?")
+		}
+		args = append(args, f.From.Unix())
+	}
+	if !f.To.IsZero() {
+		if f.overlapping() {
+			conds = append(conds, "start_unix < ?")
+		} else {
+			conds = append(conds, "end_unix <= ?")
+		}
+		args = append(args, f.To.Unix())
+	}
+	if f.Project != "" {
+		conds = append(conds, "project = ?")
+		args = append(args, f.Project)
+	}
+	if f.UUID != "" {
+		conds = append(conds, "uuid = ?")
+		args = append(args, f.UUID)
+	}
+	if len(conds) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
+
+// matches applies the filter to one session, like the WHERE clause of where.
+func (f SessionFilter) matches(s Session) bool {
+	if f.UUID != "" && s.uuid != f.UUID {
+		return false
+	}
+	if f.Project != "" && s.Project != f.Project {
+		return false
+	}
+	if !f.From.IsZero() {
+		if f.overlapping() && s.endUnix <= f.From.Unix() || !f.overlapping() && s.startUnix < f.From.Unix() {
+			return false
+		}
+	}
+	if !f.To.IsZero() {
+		if f.overlapping() && s.startUnix >= f.To.Unix() || !f.overlapping() && s.endUnix > f.To.Unix() {
+			return false
+		}
+	}
+	return true
+}
+
+// newSession returns a session from start to end recorded in zone tz
+// (empty for the local zone). Billing fields are left to the caller.
+func newSession(title, description, project string, start, end time.Time, tz string) Session {
+	s := Session{Title: title, Description: description, Project: project, TimeZone: tz}
+	s.setTimes(start, end)
+	return s
+}
+
+// setTimes moves the session to start and end and sets the raw duration.
+// Billed duration and earnings have to be recomputed by the caller.
+func (s *Session) setTimes(start, end time.Time) {
+	s.startUnix, s.endUnix = start.Unix(), end.Unix()
+	s.StartTime = formatSessionTime(s.startUnix, s.TimeZone)
+	s.EndTime = formatSessionTime(s.endUnix, s.TimeZone)
+	s.Difference = int64(end.Sub(start).Seconds())
+}
+
+// bill sets the billed duration and the earnings at the session's rate.
+func (s *Session) bill(billed time.Duration) {
+	s.BilledDifference = int64(billed.Seconds())
+	s.Earnings = earningsFor(s.HourlyRate, billed)
+}
+
+// prepareNew fills the defaults of a session about to be created.
+func prepareNew(s *Session) {
+	if s.uuid == "" {
+		s.uuid = uuid.New().String()
+	}
+	if s.TimeZone == "" {
+		s.TimeZone = localZoneName()
+	}
+	if s.Currency == "" {
+		s.Currency = defaultCurrency
+	}
+	if s.CreatedBy == "" {
+		s.CreatedBy = getDeviceID()
+	}
+	s.StartTime = formatSessionTime(s.startUnix, s.TimeZone)
+	s.EndTime = formatSessionTime(s.endUnix, s.TimeZone)
+}
+
+// sqliteStore keeps sessions in the work_sessions table.
+type sqliteStore struct {
+	db *sql.DB
+}
+
+func newSQLiteStore(db *sql.DB) *sqliteStore {
+	return &sqliteStore{db: db}
+}
+
+// sessionColumns reads missing amounts as zero; older migrations left them NULL.
+const sessionColumns = "id, uuid, title, COALESCE(description, ''), COALESCE(project, ''), start_unix, end_unix, COALESCE(timezone, ''), difference, billed_difference, COALESCE(hourly_rate_cents, 0), COALESCE(earnings_cents, 0), COALESCE(currency, 'EUR'), created_by, user_name, user_email"
+
+// scanSession reads a row selected with sessionColumns.
+func scanSession(row interface{ Scan(...any) error }) (Session, error) {
+	var s Session
+	err := row.Scan(&s.ID, &s.uuid, &s.Title, &s.Description, &s.Project, &s.startUnix, &s.endUnix, &s.TimeZone, &s.Difference, &s.BilledDifference, &s.HourlyRate, &s.Earnings, &s.Currency, &s.CreatedBy, &s.UserName, &s.UserEmail)
+	if err != nil {
+		return s, err
+	}
+	s.StartTime = formatSessionTime(s.startUnix, s.TimeZone)
+	s.EndTime = formatSessionTime(s.endUnix, s.TimeZone)
+	return s, nil
+}
+
+func (st *sqliteStore) Create(s *Session) error {
+	prepareNew(s)
+	start, end := time.Unix(s.startUnix, 0), time.Unix(s.endUnix, 0)
+	query := `INSERT INTO work_sessions (uuid, title, description, project, start_time, end_time, start_unix, end_unix, timezone, difference, billed_difference, hourly_rate_cents, earnings_cents, currency, created_by, user_name, user_email) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	res, err := st.db.Exec(query, s.uuid, s.Title, s.Description, s.Project, storedTime(start, s.TimeZone), storedTime(end, s.TimeZone), s.startUnix, s.endUnix, s.TimeZone, s.Difference, s.BilledDifference, s.HourlyRate, s.Earnings, s.Currency, s.CreatedBy, s.UserName, s.UserEmail)
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	s.ID = int(id)
+	return nil
+}
+
+func (st *sqliteStore) Get(id int) (Session, error) {
+	s, err := scanSession(st.db.QueryRow("SELECT "+sessionColumns+" FROM work_sessions WHERE id = ?", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return s, errSessionNotFound
+	}
+	return s, err
+}
+
+func (st *sqliteStore) Update(s Session) error {
+	start, end := time.Unix(s.startUnix, 0), time.Unix(s.endUnix, 0)
+	query := `UPDATE work_sessions SET title = ?, description = ?, project = ?, start_time = ?, end_time = ?, start_unix = ?, end_unix = ?, timezone = ?, difference = ?, billed_difference = ?, hourly_rate_cents = ?, earnings_cents = ?, currency = ?, user_name = ?, user_email = ? WHERE id = ?`
+	res, err := st.db.Exec(query, s.Title, s.Description, s.Project, storedTime(start, s.TimeZone), storedTime(end, s.TimeZone), s.startUnix, s.endUnix, s.TimeZone, s.Difference, s.BilledDifference, s.HourlyRate, s.Earnings, s.Currency, s.UserName, s.UserEmail, s.ID)
+	if err != nil {
+		return err
+	}
+	return requireRow(res)
+}
+
+func (st *sqliteStore) Delete(id int) error {
+	res, err := st.db.Exec("DELETE FROM work_sessions WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	return requireRow(res)
+}
+
+// requireRow turns a statement that changed no row into errSessionNotFound.
+func requireRow(res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return errSessionNotFound
+	}
+	return nil
+}
+
+func (st *sqliteStore) List(filter SessionFilter) ([]Session, error) {
+	where, args := filter.where()
+	rows, err := st.db.Query("SELECT "+sessionColumns+" FROM work_sessions"+where+" ORDER BY end_unix DESC, id DESC", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []Session
+	for rows.Next() {
+		s, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		if filter.Mode == rangeClipped {
+			s = clipSession(s, filter.From, filter.To)
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, rows.Err()
+}
+
+// RecentTasks returns the most recently used distinct (title, description,
+// project, rate, currency) combinations.
+func (st *sqliteStore) RecentTasks(limit int) ([]RecentTask, error) {
+	query := `SELECT title, COALESCE(description, ''), COALESCE(project, ''), COALESCE(hourly_rate_cents, 0), COALESCE(currency, 'EUR'), MAX(end_unix) AS last_used
+	FROM work_sessions
+	GROUP BY title, COALESCE(description, ''), COALESCE(project, ''), COALESCE(hourly_rate_cents, 0), COALESCE(currency, 'EUR')
+	ORDER BY last_used DESC
+	LIMIT ?`
+	rows, err := st.db.Query(query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tasks []RecentTask
+	for rows.Next() {
+		var t RecentTask
+		var lastUsed sql.NullInt64
+		if err := rows.Scan(&t.Title, &t.Description, &t.Project, &t.HourlyRate, &t.Currency, &lastUsed); err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, t)
+	}
+	return tasks, rows.Err()
+}
+
+// memoryStore keeps sessions in memory with the same semantics as sqliteStore,
+// e.g. for trying out changes without a database file.
+type memoryStore struct {
+	mu       sync.Mutex
+	sessions map[int]Session
+	nextID   int
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{sessions: map[int]Session{}, nextID: 1}
+}
+
+func (m *memoryStore) Create(s *Session) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	prepareNew(s)
+	for _, other := range m.sessions {
+		if other.uuid == s.uuid {
+			return errors.New("a session with uuid " + s.uuid + " already exists")
+		}
+	}
+	s.ID = m.nextID
+	m.nextID++
+	m.sessions[s.ID] = *s
+	return nil
+}
+
+func (m *memoryStore) Get(id int) (Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.sessions[id]
+	if !ok {
+		return Session{}, errSessionNotFound
+	}
+	return s, nil
+}
+
+func (m *memoryStore) Update(s Session) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	old, ok := m.sessions[s.ID]
+	if !ok {
+		return errSessionNotFound
+	}
+	s.uuid, s.CreatedBy = old.uuid, old.CreatedBy
+	s.StartTime = formatSessionTime(s.startUnix, s.TimeZone)
+	s.EndTime = formatSessionTime(s.endUnix, s.TimeZone)
+	m.sessions[s.ID] = s
+	return nil
+}
+
+func (m *memoryStore) Delete(id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.sessions[id]; !ok {
+		return errSessionNotFound
+	}
+	delete(m.sessions, id)
+	return nil
+}
+
+func (m *memoryStore) List(filter SessionFilter) ([]Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var sessions []Session
+	for _, s := range m.sessions {
+		if filter.matches(s) {
+			sessions = append(sessions, s)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		if sessions[i].endUnix != sessions[j].endUnix {
+			return sessions[i].endUnix > sessions[j].endUnix
+		}
+		return sessions[i].ID > sessions[j].ID
+	})
+	if filter.Mode == rangeClipped {
+		for i := range sessions {
+			sessions[i] = clipSession(sessions[i], filter.From, filter.To)
+		}
+	}
+	return sessions, nil
+}
+
+func (m *memoryStore) RecentTasks(limit int) ([]RecentTask, error) {
+	sessions, err := m.List(SessionFilter{})
+	if err != nil {
+		return nil, err
+	}
+	return recentTasks(sessions, limit), nil
+}
+
+// recentTasks picks the distinct tasks of sessions sorted newest first.
+func recentTasks(sessions []Session, limit int) []RecentTask {
+	seen := map[RecentTask]bool{}
+	var tasks []RecentTask
+	for _, s := range sessions {
+		if len(tasks) == limit {
+			break
+		}
+		t := RecentTask{Title: s.Title, Description: s.Description, Project: s.Project, HourlyRate: s.HourlyRate, Currency: s.Currency}
+		if !seen[t] {
+			seen[t] = true
+			tasks = append(tasks, t)
+		}
+	}
+	return tasks
+}
+
+// sessionExists reports whether a session with the uuid is stored.
+func sessionExists(store SessionStore, sessionUUID string) (bool, error) {
+	sessions, err := store.List(SessionFilter{UUID: sessionUUID})
+	return len(sessions) > 0, err
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+)
+
+// openTestDB returns an empty in-memory database with the full schema.
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := openSQLite(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// every connection would get its own in-memory database
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	if err := prepareSchema(db); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// storeImplementations returns a fresh, empty store of every implementation.
+func storeImplementations(t *testing.T) map[string]SessionStore {
+	t.Helper()
+	return map[string]SessionStore{
+		"sqlite": newSQLiteStore(openTestDB(t)),
+		"memory": newMemoryStore(),
+	}
+}
+
+// testTime returns a time on 2024-03-04 in UTC.
+func testTime(h, m int) time.Time {
+	return time.Date(2024, 3, 4, h, m, 0, 0, time.UTC)
+}
+
+// createTestSession stores a session from start to end and returns it as stored.
+func createTestSession(t *testing.T, store SessionStore, title, project string, start, end time.Time) Session {
+	t.Helper()
+	s := newSession(title, "", project, start, end, "UTC")
+	s.HourlyRate = 6000
+	s.bill(end.Sub(start))
+	if err := store.Create(&s); err != nil {
+		t.Fatalf("Create(%q): %v", title, err)
+	}
+	return s
+}
+
+// sessionIDs returns the ids of sessions in order.
+func sessionIDs(sessions []Session) []int {
+	ids := []int{}
+	for _, s := range sessions {
+		ids = append(ids, s.ID)
+	}
+	return ids
+}
+
+func equalIDs(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestStoreCRUD(t *testing.T) {
+	for name, store := range storeImplementations(t) {
+		t.Run(name, func(t *testing.T) {
+			s := newSession("Write docs", "Chapter 1", "Website", testTime(9, 0), testTime(10, 30), "")
+			s.HourlyRate = 4550
+			s.bill(90 * time.Minute)
+			if err := store.Create(&s); err != nil {
+				t.Fatal(err)
+			}
+			if s.ID == 0 || s.uuid == "" || s.TimeZone == "" || s.Currency != defaultCurrency || s.CreatedBy == "" {
+				t.Fatalf("Create did not fill the defaults: %+v", s)
+			}
+
+			got, err := store.Get(s.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != s {
+				t.Errorf("Get = %+v, want %+v", got, s)
+			}
+
+			changed := got
+			changed.Title, changed.Project = "Write more docs", "Manual"
+			changed.setTimes(testTime(9, 0), testTime(11, 0))
+			changed.bill(2 * time.Hour)
+			changed.uuid, changed.CreatedBy = "other", "other"
+			if err := store.Update(changed); err != nil {
+				t.Fatal(err)
+			}
+			got, err = store.Get(s.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Title != "Write more docs" || got.Project != "Manual" || got.Difference != 7200 || got.Earnings != 9100 {
+				t.Errorf("Update not applied: %+v", got)
+			}
+			if got.uuid != s.uuid || got.CreatedBy != s.CreatedBy {
+				t.Errorf("Update changed uuid or created_by: %+v", got)
+			}
+
+			if err := store.Delete(s.ID); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := store.Get(s.ID); !errors.Is(err, errSessionNotFound) {
+				t.Errorf("Get after Delete: err = %v, want errSessionNotFound", err)
+			}
+			if err := store.Update(changed); !errors.Is(err, errSessionNotFound) {
+				t.Errorf("Update of deleted session: err = %v, want errSessionNotFound", err)
+			}
+			if err := store.Delete(s.ID); !errors.Is(err, errSessionNotFound) {
+				t.Errorf("Delete of deleted session: err = %v, want errSessionNotFound", err)
+			}
+		})
+	}
+}
+
+func TestStoreList(t *testing.T) {
+	for name, store := range storeImplementations(t) {
+		t.Run(name, func(t *testing.T) {
+			early := createTestSession(t, store, "Early", "Website", testTime(7, 0), testTime(8, 30))
+			inside := createTestSession(t, store, "Inside", "Website", testTime(9, 0), testTime(10, 0))
+			sameEnd := createTestSession(t, store, "Same end", "Manual", testTime(9, 30), testTime(10, 0))
+			late := createTestSession(t, store, "Late", "Manual", testTime(11, 0), testTime(13, 0))
+			from, to := testTime(8, 0), testTime(12, 0)
+
+			tests := []struct {
+				name   string
+				filter SessionFilter
+				want   []int
+			}{
+				{"all, ties by id", SessionFilter{}, []int{late.ID, sameEnd.ID, inside.ID, early.ID}},
+				{"project", SessionFilter{Project: "Website"}, []int{inside.ID, early.ID}},
+				{"uuid", SessionFilter{UUID: inside.uuid}, []int{inside.ID}},
+				{"unknown uuid", SessionFilter{UUID: "none"}, []int{}},
+				{"contained", SessionFilter{From: from, To: to}, []int{sameEnd.ID, inside.ID}},
+				{"contained is the default mode", SessionFilter{From: from, To: to, Mode: rangeContained}, []int{sameEnd.ID, inside.ID}},
+				{"overlapping", SessionFilter{From: from, To: to, Mode: rangeOverlapping}, []int{late.ID, sameEnd.ID, inside.ID, early.ID}},
+				{"overlapping excludes touching", SessionFilter{From: testTime(8, 30), To: testTime(9, 0), Mode: rangeOverlapping}, []int{}},
+				{"open end", SessionFilter{From: testTime(9, 0)}, []int{late.ID, sameEnd.ID, inside.ID}},
+				{"range and project", SessionFilter{From: from, To: to, Mode: rangeOverlapping, Project: "Manual"}, []int{late.ID, sameEnd.ID}},
+			}
+			for _, tt := range tests {
+				got, err := store.List(tt.filter)
+				if err != nil {
+					t.Fatalf("%s: %v", tt.name, err)
+				}
+				if ids := sessionIDs(got); !equalIDs(ids, tt.want) {
+					t.Errorf("%s: ids = %v, want %v", tt.name, ids, tt.want)
+				}
+			}
+
+			clipped, err := store.List(SessionFilter{From: from, To: to, Mode: rangeClipped})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ids, want := sessionIDs(clipped), []int{late.ID, sameEnd.ID, inside.ID, early.ID}; !equalIDs(ids, want) {
+				t.Fatalf("clipped: ids = %v, want %v", ids, want)
+			}
+			// Late is cut to 11:00-12:00, Early to 08:00-08:30; the others are inside
+			wantClipped := []struct {
+				start, end time.Time
+				earnings   Money
+			}{
+				{testTime(11, 0), testTime(12, 0), 6000},
+				{sameEnd.Start(), sameEnd.End(), sameEnd.Earnings},
+				{inside.Start(), inside.End(), inside.Earnings},
+				{testTime(8, 0), testTime(8, 30), 3000},
+			}
+			for i, w := range wantClipped {
+				s := clipped[i]
+				if !s.Start().Equal(w.start) || !s.End().Equal(w.end) || s.Difference != int64(w.end.Sub(w.start).Seconds()) || s.Earnings != w.earnings {
+					t.Errorf("clipped %q: %s - %s, %ds, earnings %d; want %s - %s, earnings %d",
+						s.Title, s.Start(), s.End(), s.Difference, s.Earnings, w.start, w.end, w.earnings)
+				}
+			}
+			// clipping must not change what is stored
+			stored, err := store.Get(late.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stored != late {
+				t.Errorf("clipping changed the stored session: %+v", stored)
+			}
+		})
+	}
+}
+
+func TestStoreRecentTasks(t *testing.T) {
+	for name, store := range storeImplementations(t) {
+		t.Run(name, func(t *testing.T) {
+			createTestSession(t, store, "Docs", "Website", testTime(8, 0), testTime(9, 0))
+			createTestSession(t, store, "Review", "", testTime(9, 0), testTime(10, 0))
+			createTestSession(t, store, "Docs", "Website", testTime(10, 0), testTime(11, 0))
+			createTestSession(t, store, "Docs", "Manual", testTime(11, 0), testTime(12, 0))
+
+			tasks, err := store.RecentTasks(10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := []RecentTask{
+				{Title: "Docs", Project: "Manual", HourlyRate: 6000, Currency: defaultCurrency},
+				{Title: "Docs", Project: "Website", HourlyRate: 6000, Currency: defaultCurrency},
+				{Title: "Review", HourlyRate: 6000, Currency: defaultCurrency},
+			}
+			if len(tasks) != len(want) {
+				t.Fatalf("RecentTasks = %+v, want %+v", tasks, want)
+			}
+			for i := range want {
+				if tasks[i] != want[i] {
+					t.Errorf("RecentTasks[%d] = %+v, want %+v", i, tasks[i], want[i])
+				}
+			}
+
+			tasks, err = store.RecentTasks(2)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(tasks) != 2 || tasks[0] != want[0] || tasks[1] != want[1] {
+				t.Errorf("RecentTasks(2) = %+v, want %+v", tasks, want[:2])
+			}
+		})
+	}
+}
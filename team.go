@@ -0,0 +1,591 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Team mode: every member exports their sessions as JSON, either by hand or
+// into a shared sync folder. Imported sessions of the others live in the
+// team_sessions table, apart from the own ones, and only show up in the team
+// report.
+
+// identityStore stamps new sessions with the user in the settings.
+type identityStore struct {
+	SessionStore
+	cfg *Config
+}
+
+func (st *identityStore) Create(s *Session) error {
+	if s.UserName == "" && s.UserEmail == "" {
+		s.UserName, s.UserEmail = st.cfg.UserName, st.cfg.UserEmail
+	}
+	return st.SessionStore.Create(s)
+}
+
+// withIdentity returns own sessions with the user of the settings filled in
+// where they predate it.
+func withIdentity(sessions []Session, cfg *Config) []Session {
+	for i := range sessions {
+		if sessions[i].UserName == "" && sessions[i].UserEmail == "" {
+			sessions[i].UserName, sessions[i].UserEmail = cfg.UserName, cfg.UserEmail
+		}
+	}
+	return sessions
+}
+
+// person returns who tracked s as shown in team reports: name and email if
+// known, else the device it was tracked on.
+func (s Session) person() string {
+	switch {
+	case s.UserName != "" && s.UserEmail != "":
+		return s.UserName + " <" + s.UserEmail + ">"
+	case s.UserName != "":
+		return s.UserName
+	case s.UserEmail != "":
+		return s.UserEmail
+	}
+	return s.CreatedBy
+}
+
+// jsonExportVersion is written to JSON exports; newer ones are refused on import.
+const jsonExportVersion = 1
+
+// jsonExport is the document of the JSON export format.
+type jsonExport struct {
+	Version    int           `json:"version"`
+	ExportedAt string        `json:"exported_at"`
+	Sessions   []jsonSession `json:"sessions"`
+}
+
+// jsonExporter writes all session fields, for importing them elsewhere; columns are ignored.
+type jsonExporter struct{}
+
+func (jsonExporter) Extension() string { return exportJSON }
+
+func (jsonExporter) Export(w io.Writer, job ExportJob) error {
+	return writeSessionsJSON(w, job.Sessions, time.Now())
+}
+
+// writeSessionsJSON writes sessions as a JSON export made at now.
+func writeSessionsJSON(w io.Writer, sessions []Session, now time.Time) error {
+	doc := jsonExport{Version: jsonExportVersion, ExportedAt: now.Format(time.RFC3339), Sessions: []jsonSession{}}
+	for _, s := range sessions {
+		doc.Sessions = append(doc.Sessions, newJSONSession(s, false))
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+// readSessionsJSON reads a JSON export.
+func readSessionsJSON(r io.Reader) ([]Session, error) {
+	var doc jsonExport
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("not a TaskTracker JSON export: %w", err)
+	}
+	if doc.Version < 1 || doc.Version > jsonExportVersion {
+		return nil, fmt.Errorf("unsupported JSON export version %d", doc.Version)
+	}
+	sessions := make([]Session, 0, len(doc.Sessions))
+	for i, js := range doc.Sessions {
+		s, err := js.session()
+		if err != nil {
+			return nil, fmt.Errorf("session %d: %w", i+1, err)
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, nil
+}
+
+// session converts an exported session back. The ID is not kept; sessions
+// are identified by their uuid across databases.
+func (js jsonSession) session() (Session, error) {
+	if js.UUID == "" {
+		return Session{}, errors.New("missing uuid")
+	}
+	start, err := time.Parse(time.RFC3339, js.Start)
+	if err != nil {
+		return Session{}, fmt.Errorf("invalid start: %w", err)
+	}
+	end, err := time.Parse(time.RFC3339, js.End)
+	if err != nil {
+		return Session{}, fmt.Errorf("invalid end: %w", err)
+	}
+	amount := func(v string) (Money, error) {
+		if v == "" {
+			return 0, nil
+		}
+		return parseMoney(v)
+	}
+	s := newSession(js.Title, js.Description, js.Project, start, end, js.TimeZone)
+	s.uuid = js.UUID
+	s.Difference = js.DurationSecs
+	s.BilledDifference = js.BilledSecs
+	if s.HourlyRate, err = amount(js.HourlyRate); err != nil {
+		return Session{}, err
+	}
+	if s.Earnings, err = amount(js.Earnings); err != nil {
+		return Session{}, err
+	}
+	s.Currency = js.Currency
+	if s.Currency == "" {
+		s.Currency = defaultCurrency
+	}
+	s.CreatedBy, s.UserName, s.UserEmail = js.CreatedBy, js.UserName, js.UserEmail
+	return s, nil
+}
+
+// createTeamTable ensures the table of imported team sessions exists. Source
+// is the export file they came from; a newer file of the same name replaces them.
+func createTeamTable(db *sql.DB) error {
+	query := `
+    CREATE TABLE IF NOT EXISTS team_sessions (
+        uuid TEXT PRIMARY KEY,
+        source TEXT NOT NULL,
+        title TEXT NOT NULL,
+        description TEXT NOT NULL,
+        project TEXT NOT NULL,
+        start_unix INTEGER NOT NULL,
+        end_unix INTEGER NOT NULL,
+        timezone TEXT NOT NULL,
+        difference INTEGER NOT NULL,
+        billed_difference INTEGER NOT NULL,
+        hourly_rate_cents INTEGER NOT NULL,
+        earnings_cents INTEGER NOT NULL,
+        currency TEXT NOT NULL,
+        created_by TEXT NOT NULL,
+        user_name TEXT NOT NULL,
+        user_email TEXT NOT NULL
+    );`
+	_, err := db.Exec(query)
+	return err
+}
+
+// importTeamSessions replaces the sessions imported from source with sessions.
+// Sessions of this workspace are skipped. Title and description are
+// encrypted like the own ones. It returns how many sessions were stored.
+func importTeamSessions(db *sql.DB, fc *fieldCipher, source string, sessions []Session) (int, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM team_sessions WHERE source = ?", source); err != nil {
+		return 0, err
+	}
+	stored := 0
+	for _, s := range sessions {
+		var own int
+		if err := tx.QueryRow("SELECT COUNT(*) FROM work_sessions WHERE uuid = ?", s.uuid).Scan(&own); err != nil {
+			return 0, err
+		}
+		if own > 0 {
+			continue
+		}
+		title, err := fc.seal("title", s.uuid, s.Title)
+		if err != nil {
+			return 0, err
+		}
+		description, err := fc.seal("description", s.uuid, s.Description)
+		if err != nil {
+			return 0, err
+		}
+		_, err = tx.Exec(`INSERT INTO team_sessions (uuid, source, title, description, project, start_unix, end_unix, timezone, difference, billed_difference, hourly_rate_cents, earnings_cents, currency, created_by, user_name, user_email)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	ON CONFLICT(uuid) DO UPDATE SET source = excluded.source, title = excluded.title, description = excluded.description, project = excluded.project,
+		start_unix = excluded.start_unix, end_unix = excluded.end_unix, timezone = excluded.timezone, difference = excluded.difference,
+		billed_difference = excluded.billed_difference, hourly_rate_cents = excluded.hourly_rate_cents, earnings_cents = excluded.earnings_cents,
+		currency = excluded.currency, created_by = excluded.created_by, user_name = excluded.user_name, user_email = excluded.user_email`,
+			s.uuid, source, title, description, s.Project, s.startUnix, s.endUnix, s.TimeZone, s.Difference, s.BilledDifference, s.HourlyRate, s.Earnings, s.Currency, s.CreatedBy, s.UserName, s.UserEmail)
+		if err != nil {
+			return 0, err
+		}
+		stored++
+	}
+	return stored, tx.Commit()
+}
+
+// teamSessions returns the imported sessions selected by filter.
+func teamSessions(db *sql.DB, fc *fieldCipher, filter SessionFilter) ([]Session, error) {
+	where, args := filter.where()
+	rows, err := db.Query("SELECT uuid, title, description, project, start_unix, end_unix, timezone, difference, billed_difference, hourly_rate_cents, earnings_cents, currency, created_by, user_name, user_email FROM team_sessions"+where+" ORDER BY end_unix DESC", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []Session
+	for rows.Next() {
+		var s Session
+		if err := rows.Scan(&s.uuid, &s.Title, &s.Description, &s.Project, &s.startUnix, &s.endUnix, &s.TimeZone, &s.Difference, &s.BilledDifference, &s.HourlyRate, &s.Earnings, &s.Currency, &s.CreatedBy, &s.UserName, &s.UserEmail); err != nil {
+			return nil, err
+		}
+		if s.Title, err = fc.open("title", s.uuid, s.Title); err != nil {
+			return nil, err
+		}
+		if s.Description, err = fc.open("description", s.uuid, s.Description); err != nil {
+			return nil, err
+		}
+		if filter.Mode == rangeClipped {
+			s = clipSession(s, filter.From, filter.To)
+		}
+		s.StartTime = formatSessionTime(s.startUnix, s.TimeZone)
+		s.EndTime = formatSessionTime(s.endUnix, s.TimeZone)
+		sessions = append(sessions, s)
+	}
+	return sessions, rows.Err()
+}
+
+// teamFileName returns the file the own sessions are written to in the team folder.
+func teamFileName(cfg *Config) string {
+	name := cfg.UserEmail
+	if name == "" {
+		name = cfg.UserName
+	}
+	if name == "" {
+		name = getDeviceID()
+	}
+	name = strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("@.-_", r) {
+			return r
+		}
+		return '_'
+	}, name)
+	return name + "." + exportJSON
+}
+
+// syncTeamFolder writes the own sessions into the team folder and imports the
+// exports of everyone else there, dropping the sessions of exports that were
+// removed from the folder. A broken file does not stop the others;
+// its error is returned with the counts. The own sessions are written
+// decrypted, so callers ask first when the workspace is encrypted.
+func syncTeamFolder(db *sql.DB, fc *fieldCipher, store SessionStore, cfg *Config) (files, imported int, err error) {
+	dir := cfg.TeamFolder
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return 0, 0, fmt.Errorf("team folder %q is not a folder", dir)
+	}
+
+	// write to a temporary file first, so sync tools never pick up half of it
+	own, err := store.List(SessionFilter{})
+	if err != nil {
+		return 0, 0, err
+	}
+	mine := teamFileName(cfg)
+	tmp, err := os.CreateTemp(dir, ".tasktracker-*.tmp")
+	if err != nil {
+		return 0, 0, err
+	}
+	defer os.Remove(tmp.Name())
+	if err := writeSessionsJSON(tmp, withIdentity(own, cfg), time.Now()); err != nil {
+		tmp.Close()
+		return 0, 0, err
+	}
+	if err := tmp.Close(); err != nil {
+		return 0, 0, err
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(dir, mine)); err != nil {
+		return 0, 0, err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, 0, err
+	}
+	var errs []error
+	var sources []string
+	for _, e := range entries {
+		if e.IsDir() || e.Name() == mine || filepath.Ext(e.Name()) != "."+exportJSON {
+			continue
+		}
+		sources = append(sources, e.Name())
+		n, err := importTeamFile(db, fc, filepath.Join(dir, e.Name()))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", e.Name(), err))
+			continue
+		}
+		files++
+		imported += n
+	}
+	if err := forgetTeamSources(db, sources); err != nil {
+		errs = append(errs, err)
+	}
+	return files, imported, errors.Join(errs...)
+}
+
+// forgetTeamSources deletes the sessions imported from files that are no
+// longer in the team folder; sources are the files still there. Sessions of
+// files that are there but could not be read are kept.
+func forgetTeamSources(db *sql.DB, sources []string) error {
+	query := "DELETE FROM team_sessions"
+	args := make([]any, len(sources))
+	for i, source := range sources {
+		args[i] = source
+	}
+	if len(sources) > 0 {
+		query += " WHERE source NOT IN (?" + strings.Repeat(", ?", len(sources)-1) + ")"
+	}
+	_, err := db.Exec(query, args...)
+	return err
+}
+
+// importTeamFile imports the JSON export at path; its file name is the source.
+func importTeamFile(db *sql.DB, fc *fieldCipher, path string) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	sessions, err := readSessionsJSON(f)
+	if err != nil {
+		return 0, err
+	}
+	return importTeamSessions(db, fc, filepath.Base(path), sessions)
+}
+
+// TeamTotal sums the sessions of one person, one project or one person's project.
+type TeamTotal struct {
+	Person   string
+	Project  string
+	Sessions int
+	Tracked  time.Duration
+	Billed   time.Duration
+	Earnings map[string]Money // per currency
+}
+
+// teamTotals sums sessions grouped by the person and project that key returns,
+// sorted by person and project.
+func teamTotals(sessions []Session, key func(Session) (person, project string)) []TeamTotal {
+	type group struct{ person, project string }
+	totals := map[group]*TeamTotal{}
+	for _, s := range sessions {
+		person, project := key(s)
+		g := group{person, project}
+		t, ok := totals[g]
+		if !ok {
+			t = &TeamTotal{Person: person, Project: project, Earnings: map[string]Money{}}
+			totals[g] = t
+		}
+		t.Sessions++
+		t.Tracked += s.Duration()
+		t.Billed += s.Billed()
+		t.Earnings[s.Currency] += s.Earnings
+	}
+
+	result := make([]TeamTotal, 0, len(totals))
+	for _, t := range totals {
+		result = append(result, *t)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Person != result[j].Person {
+			return result[i].Person < result[j].Person
+		}
+		return result[i].Project < result[j].Project
+	})
+	return result
+}
+
+// byPerson, byProject and byPersonProject are the groupings of the team report.
+func byPerson(s Session) (string, string)        { return s.person(), "" }
+func byProject(s Session) (string, string)       { return "", s.Project }
+func byPersonProject(s Session) (string, string) { return s.person(), s.Project }
+
+// writeTeamCSV writes the totals per person and project, with one earnings
+// column per currency.
+func writeTeamCSV(w io.Writer, totals []TeamTotal, delimiter rune) error {
+	_, _ = w.Write([]byte{0xEF, 0xBB, 0xBF})
+	writer := csv.NewWriter(w)
+	writer.Comma = delimiter
+
+	var currencies []string
+	seen := map[string]bool{}
+	for _, t := range totals {
+		for code := range t.Earnings {
+			if !seen[code] {
+				seen[code] = true
+				currencies = append(currencies, code)
+			}
+		}
+	}
+	sort.Strings(currencies)
+
+	header := []string{"Person", "Project", "Sessions", "Tracked Hours", "Billed Hours"}
+	for _, code := range currencies {
+		header = append(header, "Earnings "+code)
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	for _, t := range totals {
+		record := []string{t.Person, t.Project, fmt.Sprint(t.Sessions), hours(t.Tracked), hours(t.Billed)}
+		for _, code := range currencies {
+			record = append(record, t.Earnings[code].Decimal())
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// createTeamTab imports the exports of other members and reports the hours and
+// earnings of everyone per person and project.
+func createTeamTab(db *sql.DB, fc *fieldCipher, store SessionStore, cfg *Config) fyne.CanvasObject {
+	statusLabel := widget.NewLabel("")
+	statusLabel.Wrapping = fyne.TextWrapWord
+	parent := fyne.CurrentApp().Driver().AllWindows()[0]
+
+	identity := "You are " + Session{UserName: cfg.UserName, UserEmail: cfg.UserEmail, CreatedBy: getDeviceID()}.person()
+	if cfg.UserName == "" && cfg.UserEmail == "" {
+		identity += ". Set your name and email in the settings so the others see who you are."
+	}
+	identityLabel := widget.NewLabel(identity)
+	identityLabel.Wrapping = fyne.TextWrapWord
+
+	syncNow := func() {
+		files, imported, err := syncTeamFolder(db, fc, store, cfg)
+		if err != nil && files == 0 && imported == 0 {
+			showError("syncing team folder", err)
+			return
+		}
+		text := fmt.Sprintf("Wrote %s, imported %d sessions from %d files", teamFileName(cfg), imported, files)
+		if err != nil {
+			text += ". Skipped: " + err.Error()
+		}
+		statusLabel.SetText(text)
+	}
+	syncBtn := widget.NewButton("Sync with team folder", func() {
+		if cfg.TeamFolder == "" {
+			statusLabel.SetText("Choose a team folder in the settings first")
+			return
+		}
+		// the export is plain JSON, so it would undo the encryption in the folder
+		if fc.enabled() {
+			dialog.ShowConfirm("Sync encrypted workspace",
+				"This workspace is encrypted, but the team folder gets your sessions as plain JSON: everyone with access to the folder can read their titles and descriptions. Sync anyway?",
+				func(ok bool) {
+					if ok {
+						syncNow()
+					}
+				}, parent)
+			return
+		}
+		syncNow()
+	})
+
+	importBtn := widget.NewButton("Import a member's JSON export...", func() {
+		fd := dialog.NewFileOpen(func(r fyne.URIReadCloser, err error) {
+			if r == nil {
+				return
+			}
+			defer r.Close()
+			sessions, err := readSessionsJSON(r)
+			if err != nil {
+				statusLabel.SetText("Cannot import " + r.URI().Name() + ": " + err.Error())
+				return
+			}
+			n, err := importTeamSessions(db, fc, r.URI().Name(), sessions)
+			if err != nil {
+				showError("importing team sessions", err)
+				return
+			}
+			statusLabel.SetText(fmt.Sprintf("Imported %d sessions from %s", n, r.URI().Name()))
+		}, parent)
+		fd.Show()
+	})
+
+	// report over all members, the own sessions included
+	rangeStart := newTimeEntry("Range start (empty: all sessions)")
+	rangeEnd := newTimeEntry("Range end (empty: now)")
+	rangeModeSelect := newRangeModeSelect()
+	report := widget.NewLabel("")
+
+	load := func() ([]Session, bool) {
+		from, to, _, err := parseOptionalRange(rangeStart.Text, rangeEnd.Text, time.Now(), time.Local)
+		if err != nil {
+			statusLabel.SetText("Invalid time range: " + err.Error())
+			return nil, false
+		}
+		filter := SessionFilter{From: from, To: to, Mode: RangeMode(rangeModeSelect.Selected)}
+		own, err := store.List(filter)
+		if err != nil {
+			showError("reading sessions", err)
+			return nil, false
+		}
+		team, err := teamSessions(db, fc, filter)
+		if err != nil {
+			showError("reading team sessions", err)
+			return nil, false
+		}
+		return append(withIdentity(own, cfg), team...), true
+	}
+
+	showBtn := widget.NewButton("Show team report", func() {
+		sessions, ok := load()
+		if !ok {
+			return
+		}
+		line := func(name string, t TeamTotal) string {
+			return fmt.Sprintf("%s: %s h tracked, %s h billed, %s\n", name, hours(t.Tracked), hours(t.Billed), formatTotals(t.Earnings))
+		}
+		text := "Per person\n"
+		for _, t := range teamTotals(sessions, byPerson) {
+			text += line(t.Person, t)
+		}
+		text += "\nPer project\n"
+		for _, t := range teamTotals(sessions, byProject) {
+			text += line(projectLabel(t.Project), t)
+		}
+		text += "\nPer person and project\n"
+		for _, t := range teamTotals(sessions, byPersonProject) {
+			text += line(t.Person+" / "+projectLabel(t.Project), t)
+		}
+		report.SetText(text)
+		statusLabel.SetText(fmt.Sprintf("%d sessions", len(sessions)))
+	})
+
+	csvBtn := widget.NewButton("Export team report to CSV", func() {
+		sessions, ok := load()
+		if !ok {
+			return
+		}
+		totals := teamTotals(sessions, byPersonProject)
+		filename := "team_" + time.Now().Format("2006-01-02") + ".csv"
+		showExportDialog(cfg, filename, statusLabel, func(w fyne.URIWriteCloser) (string, error) {
+			if err := writeTeamCSV(w, totals, cfg.csvDelimiter()); err != nil {
+				return "", err
+			}
+			return "Exported team report to " + w.URI().Name(), nil
+		})
+	})
+
+	return container.NewVScroll(container.NewVBox(
+		statusLabel,
+		identityLabel,
+		syncBtn,
+		importBtn,
+		widget.NewSeparator(),
+		widget.NewLabel("Team report"),
+		rangeStart,
+		rangeEnd,
+		container.NewHBox(widget.NewLabel("Sessions on the range edges:"), rangeModeSelect),
+		container.NewHBox(showBtn, csvBtn),
+		report,
+	))
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// teamMember returns a session tracked by someone else at h:00 for an hour.
+func teamMember(email string, h int) Session {
+	s := newSession(fmt.Sprintf("%s at %d", email, h), "", "Website", testTime(h, 0), testTime(h+1, 0), "UTC")
+	s.uuid = fmt.Sprintf("00000000-0000-4000-8000-%012d", h)
+	s.CreatedBy, s.UserEmail = "laptop", email
+	s.bill(time.Hour)
+	return s
+}
+
+// writeTeamFile writes sessions as the export name in the team folder dir.
+func writeTeamFile(t *testing.T, dir, name string, sessions ...Session) {
+	t.Helper()
+	f, err := os.Create(filepath.Join(dir, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := writeSessionsJSON(f, sessions, testTime(18, 0)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// teamTitles returns the sorted titles of the imported sessions matching filter.
+func teamTitles(t *testing.T, db *sql.DB, filter SessionFilter) string {
+	t.Helper()
+	sessions, err := teamSessions(db, &fieldCipher{}, filter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var titles []string
+	for _, s := range sessions {
+		titles = append(titles, s.Title)
+	}
+	sort.Strings(titles)
+	return strings.Join(titles, ", ")
+}
+
+func TestSyncTeamFolder(t *testing.T) {
+	db := openTestDB(t)
+	store := newSQLiteStore(db)
+	createTestSession(t, store, "Own work", "Website", testTime(8, 0), testTime(9, 0))
+	dir := t.TempDir()
+	cfg := &Config{TeamFolder: dir, UserEmail: "me@example.com"}
+
+	writeTeamFile(t, dir, "ada.json", teamMember("ada", 9), teamMember("ada", 11))
+	writeTeamFile(t, dir, "bob.json", teamMember("bob", 13))
+	writeTeamFile(t, dir, "carl.json", teamMember("carl", 15))
+	files, imported, err := syncTeamFolder(db, &fieldCipher{}, store, cfg)
+	if err != nil || files != 3 || imported != 4 {
+		t.Fatalf("sync = %d files, %d sessions, %v; want 3 files, 4 sessions", files, imported, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, teamFileName(cfg))); err != nil {
+		t.Errorf("own sessions not written: %v", err)
+	}
+
+	// bob leaves the team, carl's file is broken by a half finished sync
+	if err := os.Remove(filepath.Join(dir, "bob.json")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "carl.json"), []byte(`{"version": 1, "sessions": [`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	files, imported, err = syncTeamFolder(db, &fieldCipher{}, store, cfg)
+	if err == nil || !strings.Contains(err.Error(), "carl.json") || files != 1 || imported != 2 {
+		t.Errorf("second sync = %d files, %d sessions, %v; want 1 file, 2 sessions and the error of carl.json", files, imported, err)
+	}
+	if got, want := teamTitles(t, db, SessionFilter{}), "ada at 11, ada at 9, carl at 15"; got != want {
+		t.Errorf("team sessions after bob left = %q, want %q", got, want)
+	}
+}
+
+func TestTeamSessionsFilter(t *testing.T) {
+	db := openTestDB(t)
+	sessions := []Session{teamMember("ada", 9), teamMember("ada", 11), teamMember("bob", 13)}
+	sessions[2].Project = "Manual"
+	if _, err := importTeamSessions(db, &fieldCipher{}, "team.json", sessions); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		filter SessionFilter
+		want   string
+	}{
+		{"all", SessionFilter{}, "ada at 11, ada at 9, bob at 13"},
+		{"contained", SessionFilter{From: testTime(9, 30), To: testTime(14, 0)}, "ada at 11, bob at 13"},
+		{"overlapping", SessionFilter{From: testTime(9, 30), To: testTime(11, 30), Mode: rangeOverlapping}, "ada at 11, ada at 9"},
+		{"overlapping excludes touching", SessionFilter{From: testTime(10, 0), To: testTime(11, 0), Mode: rangeOverlapping}, ""},
+		{"project", SessionFilter{Project: "Manual"}, "bob at 13"},
+		{"uuid", SessionFilter{UUID: sessions[1].uuid}, "ada at 11"},
+	}
+	for _, tt := range tests {
+		if got := teamTitles(t, db, tt.filter); got != tt.want {
+			t.Errorf("%s: %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	clipped, err := teamSessions(db, &fieldCipher{}, SessionFilter{From: testTime(9, 30), To: testTime(11, 30), Mode: rangeClipped})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(clipped) != 2 || clipped[0].Difference != 1800 || clipped[1].Difference != 1800 {
+		t.Errorf("clipped = %+v, want two half hours", clipped)
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// templateFormatPrefix marks export formats that run a user template,
+// e.g. "template:invoice.csv.tmpl".
+const templateFormatPrefix = "template:"
+
+// userTemplates lists the *.tmpl files in templateDir, leaving out the
+// overrides of the built-in reports, which are not export formats.
+func userTemplates() ([]string, error) {
+	entries, err := os.ReadDir(templateDir())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".tmpl") {
+			continue
+		}
+		if name := e.Name(); name != markdownReportTemplate && name != htmlReportTemplate {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// exportTargets returns the built-in export formats followed by the user templates.
+func exportTargets() []string {
+	targets := append([]string(nil), exportFormats...)
+	names, _ := userTemplates()
+	for _, name := range names {
+		targets = append(targets, templateFormatPrefix+name)
+	}
+	return targets
+}
+
+// templateName returns the template file of a template format.
+func templateName(format string) (string, bool) {
+	return strings.CutPrefix(format, templateFormatPrefix)
+}
+
+// templateExtension derives the output extension from the template name:
+// "invoice.csv.tmpl" writes .csv files, "notes.tmpl" writes .txt files.
+func templateExtension(name string) string {
+	ext := filepath.Ext(strings.TrimSuffix(name, ".tmpl"))
+	if ext == "" {
+		return "txt"
+	}
+	return ext[1:]
+}
+
+// templateFuncs extends reportFuncs with helpers for text formats.
+var templateFuncs = map[string]any{
+	"csv": csvField,
+}
+
+// csvField quotes a value for CSV output if it needs quoting.
+func csvField(s string) string {
+	if !strings.ContainsAny(s, ",;\t\"\r\n") {
+		return s
+	}
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+// writeTemplateExport runs the user template name with the sessions and their
+// aggregates (see ReportData). *.html.tmpl templates use html/template and
+// escape their values, all others use text/template so they can produce any
+// text format.
+func writeTemplateExport(w io.Writer, name string, data ReportData) error {
+	if name != filepath.Base(name) {
+		return fmt.Errorf("invalid template name %q", name)
+	}
+	text, err := os.ReadFile(filepath.Join(templateDir(), name))
+	if err != nil {
+		return err
+	}
+	var tmpl reportTemplate
+	if strings.HasSuffix(name, ".html.tmpl") {
+		tmpl, err = htmltemplate.New(name).Funcs(reportFuncs).Funcs(templateFuncs).Parse(string(text))
+	} else {
+		tmpl, err = template.New(name).Funcs(reportFuncs).Funcs(templateFuncs).Parse(string(text))
+	}
+	if err != nil {
+		return err
+	}
+	return tmpl.Execute(w, data)
+}
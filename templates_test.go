@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestUserTemplates(t *testing.T) {
+	page := "<h1>{{.Title}}</h1>{{range .Sessions}}<p>{{.Title}}</p>{{end}}"
+	useTestTemplates(t, map[string]string{
+		markdownReportTemplate: "# {{.Title}}",
+		htmlReportTemplate:     page,
+		"page.html.tmpl":       page,
+		"list.txt.tmpl":        "{{range .Sessions}}{{.Title}}\n{{end}}",
+		"notes.txt":            "not a template",
+	})
+
+	names, err := userTemplates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(names, ",") != "list.txt.tmpl,page.html.tmpl" {
+		t.Errorf("userTemplates = %v, want the export templates without the report overrides", names)
+	}
+
+	s := newSession("<script>alert(1)</script>", "", "", testTime(9, 0), testTime(10, 0), "UTC")
+	data := ReportData{Title: "Tom & Jerry", Sessions: []Session{s}}
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"page.html.tmpl", "<h1>Tom &amp; Jerry</h1><p>&lt;script&gt;alert(1)&lt;/script&gt;</p>"},
+		{"list.txt.tmpl", "<script>alert(1)</script>\n"},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := writeTemplateExport(&buf, tt.name, data); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if buf.String() != tt.want {
+			t.Errorf("%s wrote %q, want %q", tt.name, buf.String(), tt.want)
+		}
+	}
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// timeInputHelp is shown as placeholder hint for time entries.
+const timeInputHelp = "e.g. 9:15, yesterday 14:00, -45m, now, 2006-01-02 15:04"
+
+// parseTimeInput parses a point in time typed by the user, relative to now in loc.
+// Accepted forms:
+//
+//	now
+//	9:15, 14:00:30, 9                  today at that time
+//	today|yesterday|tomorrow [9:15]   that day, at midnight or the given time
+//	monday [9:15]                      the most recent monday (today included)
+//	-45m, +1h30m, 2h ago               relative to now
+//	2006-01-02 [15:04[:05]]            absolute date and time
+func parseTimeInput(input string, now time.Time, loc *time.Location) (time.Time, error) {
+	s := strings.ToLower(strings.TrimSpace(input))
+	if s == "" {
+		return time.Time{}, fmt.Errorf("empty input")
+	}
+	now = now.In(loc)
+
+	if s == "now" {
+		return now.Truncate(time.Second), nil
+	}
+
+	// relative to now: "-45m", "+1h30m", "2h ago"
+	if strings.HasPrefix(s, "-") || strings.HasPrefix(s, "+") {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid duration %q", input)
+		}
+		return now.Add(d).Truncate(time.Second), nil
+	}
+	if rest, ok := strings.CutSuffix(s, " ago"); ok {
+		d, err := time.ParseDuration(strings.TrimSpace(rest))
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid duration %q", input)
+		}
+		return now.Add(-d).Truncate(time.Second), nil
+	}
+
+	// absolute date with optional time
+	if t, err := parseTimeIn(s, loc); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, loc); err == nil {
+		return t, nil
+	}
+
+	// day word with optional clock time
+	dayPart, clockPart, hasClock := strings.Cut(s, " ")
+	day, isDay := dayFromWord(dayPart, now)
+	if isDay {
+		if !hasClock {
+			return day, nil
+		}
+		return atClock(day, strings.TrimSpace(clockPart))
+	}
+
+	// clock time today
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	if t, err := atClock(today, s); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("cannot read time %q (%s)", input, timeInputHelp)
+}
+
+// dayFromWord resolves "today", "yesterday", "tomorrow" or a weekday name to midnight of that day.
+func dayFromWord(word string, now time.Time) (time.Time, bool) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	switch word {
+	case "today":
+		return today, true
+	case "yesterday":
+		return today.AddDate(0, 0, -1), true
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), true
+	}
+	for wd := time.Sunday; wd <= time.Saturday; wd++ {
+		name := strings.ToLower(wd.String())
+		if word == name || (len(word) >= 3 && strings.HasPrefix(name, word)) {
+			back := (int(now.Weekday()) - int(wd) + 7) % 7
+			return today.AddDate(0, 0, -back), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// atClock returns day at a clock time like "9", "9:15" or "14:00:30".
+func atClock(day time.Time, clock string) (time.Time, error) {
+	parts := strings.Split(clock, ":")
+	if len(parts) > 3 {
+		return time.Time{}, fmt.Errorf("invalid clock time %q", clock)
+	}
+	limits := []int{23, 59, 59}
+	values := make([]int, 3)
+	for i, p := range parts {
+		v, err := strconv.Atoi(p)
+		if err != nil || v < 0 || v > limits[i] {
+			return time.Time{}, fmt.Errorf("invalid clock time %q", clock)
+		}
+		values[i] = v
+	}
+	return time.Date(day.Year(), day.Month(), day.Day(), values[0], values[1], values[2], 0, day.Location()), nil
+}
+
+// parseTimeRangeInput parses the start and end fields of a session.
+// Besides two points in time it accepts "1h30m from 13:00" in the start field
+// (end left empty) and a plain duration like "1h30m" in the end field,
+// meaning that long after the start. The end must be after the start.
+func parseTimeRangeInput(startInput, endInput string, now time.Time, loc *time.Location) (time.Time, time.Time, error) {
+	startInput = strings.TrimSpace(startInput)
+	endInput = strings.TrimSpace(endInput)
+
+	if length, from, ok := strings.Cut(strings.ToLower(startInput), " from "); ok {
+		if endInput != "" {
+			return time.Time{}, time.Time{}, fmt.Errorf("leave the end empty when using \"<duration> from <time>\"")
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(length))
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid duration %q", length)
+		}
+		start, err := parseTimeInput(from, now, loc)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		if d <= 0 {
+			return time.Time{}, time.Time{}, fmt.Errorf("end time must be after start time")
+		}
+		return start, start.Add(d), nil
+	}
+
+	start, err := parseTimeInput(startInput, now, loc)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("start: %w", err)
+	}
+	end, err := parseEndInput(endInput, start, now, loc)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("end: %w", err)
+	}
+	if !end.After(start) {
+		return time.Time{}, time.Time{}, fmt.Errorf("end time must be after start time")
+	}
+	return start, end, nil
+}
+
+// parseOptionalRange reads optional range fields. An empty start means no
+// range (ok is false), an empty end means now. A "<duration> from <time>"
+// start is accepted like in parseTimeRangeInput.
+func parseOptionalRange(startInput, endInput string, now time.Time, loc *time.Location) (from, to time.Time, ok bool, err error) {
+	if strings.TrimSpace(startInput) == "" {
+		return time.Time{}, time.Time{}, false, nil
+	}
+	to = now
+	if strings.TrimSpace(endInput) == "" && !strings.Contains(strings.ToLower(startInput), " from ") {
+		from, err = parseTimeInput(startInput, now, loc)
+	} else {
+		from, to, err = parseTimeRangeInput(startInput, endInput, now, loc)
+	}
+	if err != nil {
+		return time.Time{}, time.Time{}, false, err
+	}
+	if to.Before(from) {
+		return time.Time{}, time.Time{}, false, fmt.Errorf("end time must be after start time")
+	}
+	return from, to, true, nil
+}
+
+// parseEndInput parses an end field: a plain duration counts from start,
+// everything else is read like parseTimeInput.
+func parseEndInput(input string, start time.Time, now time.Time, loc *time.Location) (time.Time, error) {
+	s := strings.TrimSpace(input)
+	if s != "" && s[0] >= '0' && s[0] <= '9' && !strings.ContainsAny(s, ":- ") {
+		if d, err := time.ParseDuration(s); err == nil {
+			return start.Add(d), nil
+		}
+	}
+	return parseTimeInput(s, now, loc)
+}
+
+// newTimeEntry returns an entry with a calendar button that opens a date and time picker.
+func newTimeEntry(placeholder string) *widget.Entry {
+	entry := widget.NewEntry()
+	entry.SetPlaceHolder(placeholder + " (" + timeInputHelp + ")")
+	entry.ActionItem = widget.NewButtonWithIcon("", theme.CalendarIcon(), func() {
+		showTimePicker(entry)
+	})
+	return entry
+}
+
+// showTimePicker lets the user pick a date and time and writes it into entry.
+func showTimePicker(entry *widget.Entry) {
+	initial := time.Now()
+	if t, err := parseTimeInput(entry.Text, time.Now(), time.Local); err == nil {
+		initial = t
+	}
+	picked := initial
+
+	hours := make([]string, 24)
+	for i := range hours {
+		hours[i] = fmt.Sprintf("%02d", i)
+	}
+	minutes := make([]string, 0, 12)
+	for i := 0; i < 60; i += 5 {
+		minutes = append(minutes, fmt.Sprintf("%02d", i))
+	}
+	hourSelect := widget.NewSelect(hours, nil)
+	hourSelect.SetSelected(fmt.Sprintf("%02d", initial.Hour()))
+	minuteSelect := widget.NewSelectEntry(minutes)
+	minuteSelect.SetText(fmt.Sprintf("%02d", initial.Minute()))
+
+	calendar := widget.NewCalendar(initial, func(t time.Time) {
+		picked = t
+	})
+
+	content := container.NewVBox(
+		calendar,
+		container.NewHBox(widget.NewLabel("Time:"), hourSelect, widget.NewLabel(":"), minuteSelect),
+	)
+	parent := fyne.CurrentApp().Driver().AllWindows()[0]
+	dialog.ShowCustomConfirm("Pick date and time", "OK", "Cancel", content, func(ok bool) {
+		if !ok {
+			return
+		}
+		h, _ := strconv.Atoi(hourSelect.Selected)
+		m, err := strconv.Atoi(minuteSelect.Text)
+		if err != nil || m < 0 || m > 59 {
+			m = 0
+		}
+		t := time.Date(picked.Year(), picked.Month(), picked.Day(), h, m, 0, 0, time.Local)
+		entry.SetText(t.Format(displayLayout))
+	}, parent)
+}
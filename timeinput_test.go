@@ -0,0 +1,163 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// berlin changes to summer time on Sunday 2024-03-31 at 02:00 and back on
+// Sunday 2024-10-27 at 03:00.
+var berlin = mustLoadLocation("Europe/Berlin")
+
+func mustLoadLocation(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		panic(err)
+	}
+	return loc
+}
+
+// inBerlin returns a wall clock time in berlin.
+func inBerlin(month time.Month, day, h, m int) time.Time {
+	return time.Date(2024, month, day, h, m, 0, 0, berlin)
+}
+
+func TestParseTimeInput(t *testing.T) {
+	// Monday, the day after the change to summer time
+	monday := inBerlin(time.April, 1, 10, 0)
+	springDay := inBerlin(time.March, 31, 4, 0)
+	autumnDay := inBerlin(time.October, 27, 4, 0)
+
+	tests := []struct {
+		input string
+		now   time.Time
+		want  time.Time
+	}{
+		{"now", monday.Add(1500 * time.Millisecond), monday.Add(time.Second)},
+		{" NOW ", monday, monday},
+		{"9:15", monday, inBerlin(time.April, 1, 9, 15)},
+		{"14:00:30", monday, inBerlin(time.April, 1, 14, 0).Add(30 * time.Second)},
+		{"9", monday, inBerlin(time.April, 1, 9, 0)},
+		{"today", monday, inBerlin(time.April, 1, 0, 0)},
+		{"yesterday", monday, inBerlin(time.March, 31, 0, 0)},
+		{"yesterday 9:00", monday, inBerlin(time.March, 31, 9, 0)},
+		{"tomorrow 8", monday, inBerlin(time.April, 2, 8, 0)},
+		{"monday", monday, inBerlin(time.April, 1, 0, 0)},
+		{"sun 23:00", monday, inBerlin(time.March, 31, 23, 0)},
+		{"Friday 17:30", monday, inBerlin(time.March, 29, 17, 30)},
+		{"tue", monday, inBerlin(time.March, 26, 0, 0)},
+		{"-45m", monday, inBerlin(time.April, 1, 9, 15)},
+		{"+1h30m", monday, inBerlin(time.April, 1, 11, 30)},
+		{"2h ago", monday, inBerlin(time.April, 1, 8, 0)},
+		{"12h ago", monday, inBerlin(time.March, 31, 22, 0)},
+		{"2024-03-31 01:30", monday, inBerlin(time.March, 31, 1, 30)},
+		{"2024-03-31", monday, inBerlin(time.March, 31, 0, 0)},
+		// durations are real time, so they skip the missing hour and count the repeated one
+		{"3h ago", springDay, inBerlin(time.March, 31, 0, 0)},
+		{"-3h", autumnDay, time.Date(2024, 10, 27, 0, 0, 0, 0, time.UTC)},
+		{"today 1:00", autumnDay, time.Date(2024, 10, 26, 23, 0, 0, 0, time.UTC)},
+		{"yesterday 12:00", inBerlin(time.October, 28, 9, 0), time.Date(2024, 10, 27, 11, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, err := parseTimeInput(tt.input, tt.now, berlin)
+		if err != nil {
+			t.Errorf("parseTimeInput(%q): %v", tt.input, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("parseTimeInput(%q) at %s = %s, want %s", tt.input, tt.now, got, tt.want)
+		}
+	}
+
+	for _, input := range []string{"", "soon", "25:00", "9:60", "1:2:3:4", "-x", "x ago", "mo", "monday 9:99", "2024-13-01"} {
+		if got, err := parseTimeInput(input, monday, berlin); err == nil {
+			t.Errorf("parseTimeInput(%q) = %s, want an error", input, got)
+		}
+	}
+}
+
+func TestParseEndInput(t *testing.T) {
+	monday := inBerlin(time.April, 1, 10, 0)
+	// an hour before the change to summer time
+	start := inBerlin(time.March, 31, 1, 30)
+
+	tests := []struct {
+		input string
+		want  time.Time
+	}{
+		{"1h", inBerlin(time.March, 31, 3, 30)},
+		{"90m", inBerlin(time.March, 31, 4, 0)},
+		{"2024-03-31 04:00", inBerlin(time.March, 31, 4, 0)},
+		{"1:30", inBerlin(time.April, 1, 1, 30)},
+		{"now", monday},
+		{"-30m", inBerlin(time.April, 1, 9, 30)},
+		{"2h ago", inBerlin(time.April, 1, 8, 0)},
+		{"sunday 12:00", inBerlin(time.March, 31, 12, 0)},
+	}
+	for _, tt := range tests {
+		got, err := parseEndInput(tt.input, start, monday, berlin)
+		if err != nil {
+			t.Errorf("parseEndInput(%q): %v", tt.input, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("parseEndInput(%q) = %s, want %s", tt.input, got, tt.want)
+		}
+	}
+
+	for _, input := range []string{"", "1x", "later"} {
+		if got, err := parseEndInput(input, start, monday, berlin); err == nil {
+			t.Errorf("parseEndInput(%q) = %s, want an error", input, got)
+		}
+	}
+}
+
+func TestParseTimeRangeInput(t *testing.T) {
+	monday := inBerlin(time.April, 1, 10, 0)
+
+	tests := []struct {
+		start, end string
+		wantStart  time.Time
+		wantEnd    time.Time
+	}{
+		{"9:00", "10:30", inBerlin(time.April, 1, 9, 0), inBerlin(time.April, 1, 10, 30)},
+		{"9:00", "1h30m", inBerlin(time.April, 1, 9, 0), inBerlin(time.April, 1, 10, 30)},
+		{"yesterday 23:00", "1:00", inBerlin(time.March, 31, 23, 0), inBerlin(time.April, 1, 1, 0)},
+		{"1h30m from 9:00", "", inBerlin(time.April, 1, 9, 0), inBerlin(time.April, 1, 10, 30)},
+		{"2h From 2024-03-31 1:00", " ", inBerlin(time.March, 31, 1, 0), inBerlin(time.March, 31, 4, 0)},
+		{"2024-03-31 1:00", "2024-03-31 4:00", inBerlin(time.March, 31, 1, 0), inBerlin(time.March, 31, 4, 0)},
+	}
+	for _, tt := range tests {
+		start, end, err := parseTimeRangeInput(tt.start, tt.end, monday, berlin)
+		if err != nil {
+			t.Errorf("parseTimeRangeInput(%q, %q): %v", tt.start, tt.end, err)
+			continue
+		}
+		if !start.Equal(tt.wantStart) || !end.Equal(tt.wantEnd) {
+			t.Errorf("parseTimeRangeInput(%q, %q) = %s - %s, want %s - %s", tt.start, tt.end, start, end, tt.wantStart, tt.wantEnd)
+		}
+	}
+	if _, end, _ := parseTimeRangeInput("2024-03-31 1:00", "2024-03-31 4:00", monday, berlin); end.Sub(inBerlin(time.March, 31, 1, 0)) != 2*time.Hour {
+		t.Errorf("a range over the change to summer time is not two hours long")
+	}
+
+	invalid := []struct{ start, end string }{
+		{"9:00", "9:00"},
+		{"10:00", "9:00"},
+		{"9:00", "-1h"},
+		{"9:00", ""},
+		{"11:00", "now"},
+		{"9:00", "0m"},
+		{"0m from 9:00", ""},
+		{"-1h from 9:00", ""},
+		{"1h from 9:00", "10:00"},
+		{"1x from 9:00", ""},
+		{"soon", "10:00"},
+		{"9:00", "soon"},
+	}
+	for _, tt := range invalid {
+		if start, end, err := parseTimeRangeInput(tt.start, tt.end, monday, berlin); err == nil {
+			t.Errorf("parseTimeRangeInput(%q, %q) = %s - %s, want an error", tt.start, tt.end, start, end)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	_ "time/tzdata" // zone database for Windows, where the OS does not provide one
+)
+
+// Timestamps are stored canonically as unix seconds (start_unix/end_unix) plus
+// the IANA zone the session was recorded in (timezone). start_time/end_time
+// hold the same instant as RFC 3339 text in that zone for readability only.
+
+// displayLayout is used to show session times.
+const displayLayout = "2006-01-02 15:04"
+
+// inputLayouts are accepted when the user types a date and time.
+var inputLayouts = []string{"2006-01-02 15:04:05", "2006-01-02 15:04"}
+
+// localZoneName returns the IANA name of the local time zone, or "" if unknown.
+func localZoneName() string {
+	if tz := os.Getenv("TZ"); tz != "" {
+		if _, err := time.LoadLocation(tz); err == nil {
+			return tz
+		}
+	}
+	if name := time.Local.String(); name != "Local" && name != "" {
+		return name
+	}
+	// on Unix /etc/localtime links into the zoneinfo tree
+	if target, err := filepath.EvalSymlinks("/etc/localtime"); err == nil {
+		if _, name, ok := strings.Cut(filepath.ToSlash(target), "zoneinfo/"); ok {
+			if _, err := time.LoadLocation(name); err == nil {
+				return name
+			}
+		}
+	}
+	return ""
+}
+
+// zoneLocation returns the location for an IANA zone name, falling back to local time.
+func zoneLocation(tz string) *time.Location {
+	if tz == "" {
+		return time.Local
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return time.Local
+	}
+	return loc
+}
+
+// storedTime formats t for the start_time/end_time columns in the session's zone.
+func storedTime(t time.Time, tz string) string {
+	return t.In(zoneLocation(tz)).Format(time.RFC3339)
+}
+
+// formatSessionTime shows a stored instant in the zone it was recorded in.
+// The zone abbreviation is appended when it differs from the viewer's zone,
+// so a session recorded while travelling keeps its local wall-clock time.
+func formatSessionTime(unix int64, tz string) string {
+	loc := zoneLocation(tz)
+	t := time.Unix(unix, 0).In(loc)
+	s := t.Format(displayLayout)
+
+	_, offset := t.Zone()
+	_, localOffset := t.In(time.Local).Zone()
+	if loc != time.Local && offset != localOffset {
+		s += " " + t.Format("MST")
+	}
+	return s
+}
+
+// parseTimeIn parses "YYYY-MM-DD HH:MM[:SS]" as wall-clock time in loc.
+func parseTimeIn(input string, loc *time.Location) (time.Time, error) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return time.Time{}, fmt.Errorf("empty input")
+	}
+	for _, layout := range inputLayouts {
+		if t, err := time.ParseInLocation(layout, input, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q, use YYYY-MM-DD HH:MM[:SS]", input)
+}
+
+// parseLocalTime parses user input in the local time zone.
+func parseLocalTime(input string) (time.Time, error) {
+	return parseTimeIn(input, time.Local)
+}
+
+// repairTimestamps converts rows written before the timezone column existed.
+// The unix values are the canonical instants and are kept. Only where one is
+// missing is the "2006-01-02 15:04" text read, in the local zone of this
+// machine since the recording zone is unknown; the duration, billed duration
+// and earnings then follow the new times. Rows get the local zone and
+// RFC 3339 text.
+func repairTimestamps(db *sql.DB) error {
+	rows, err := db.Query("SELECT id, start_time, COALESCE(end_time, ''), start_unix, end_unix, COALESCE(hourly_rate_cents, 0) FROM work_sessions WHERE timezone IS NULL")
+	if err != nil {
+		return err
+	}
+
+	type repair struct {
+		id         int
+		start, end time.Time
+		fromText   bool
+		rate       Money
+	}
+	var repairs []repair
+	for rows.Next() {
+		var (
+			id                 int
+			startText, endText string
+			startUnix, endUnix sql.NullInt64
+			rate               Money
+		)
+		if err := rows.Scan(&id, &startText, &endText, &startUnix, &endUnix, &rate); err != nil {
+			rows.Close()
+			return err
+		}
+		repairs = append(repairs, repair{
+			id:       id,
+			start:    legacyTime(startText, startUnix),
+			end:      legacyTime(endText, endUnix),
+			fromText: !startUnix.Valid || !endUnix.Valid,
+			rate:     rate,
+		})
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	tz := localZoneName()
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	query := "UPDATE work_sessions SET start_time = ?, end_time = ?, start_unix = ?, end_unix = ?, timezone = ? WHERE id = ?"
+	// legacy rows had no rounding, so the billed duration is the duration
+	recompute := "UPDATE work_sessions SET difference = ?, billed_difference = ?, earnings_cents = ? WHERE id = ?"
+	for _, r := range repairs {
+		if _, err := tx.Exec(query, storedTime(r.start, tz), storedTime(r.end, tz), r.start.Unix(), r.end.Unix(), tz, r.id); err != nil {
+			return err
+		}
+		if !r.fromText {
+			continue
+		}
+		duration := r.end.Sub(r.start)
+		if _, err := tx.Exec(recompute, int64(duration.Seconds()), int64(duration.Seconds()), earningsFor(r.rate, duration), r.id); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// legacyTime returns the instant of a legacy row: the unix value, or the
+// local text where the unix value is missing.
+func legacyTime(text string, unix sql.NullInt64) time.Time {
+	if unix.Valid {
+		return time.Unix(unix.Int64, 0)
+	}
+	parsed, err := parseLocalTime(text)
+	if err != nil {
+		return time.Unix(0, 0)
+	}
+	return parsed
+}
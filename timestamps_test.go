@@ -0,0 +1,58 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRepairTimestamps(t *testing.T) {
+	db, err := openSQLite(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	defer db.Close()
+
+	// the schema of the first release, with its REAL amounts and local text
+	_, err = db.Exec(`CREATE TABLE work_sessions (id INTEGER PRIMARY KEY AUTOINCREMENT, uuid TEXT UNIQUE NOT NULL, title TEXT NOT NULL, description TEXT,
+		start_time TEXT NOT NULL, end_time TEXT, start_unix INTEGER, end_unix INTEGER, difference INTEGER, hourly_rate REAL, earnings REAL, created_by TEXT NOT NULL);
+	INSERT INTO work_sessions (uuid, title, start_time, end_time, start_unix, end_unix, difference, hourly_rate, earnings, created_by)
+		VALUES ('unix', 'text disagrees', '1999-01-01 00:00', '1999-01-01 01:00', 1709542800, 1709548200, 5400, 40, 60, 'dev');
+	INSERT INTO work_sessions (uuid, title, start_time, end_time, start_unix, end_unix, difference, hourly_rate, earnings, created_by)
+		VALUES ('text', 'no unix end', '2024-03-04 09:00', '2024-03-04 11:00', 1709542800, NULL, 5400, 40, 60, 'dev');`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := prepareSchema(db); err != nil {
+		t.Fatal(err)
+	}
+
+	sessions, err := newSQLiteStore(db).List(SessionFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	byUUID := map[string]Session{}
+	for _, s := range sessions {
+		byUUID[s.uuid] = s
+	}
+
+	kept := byUUID["unix"]
+	if kept.startUnix != 1709542800 || kept.endUnix != 1709548200 || kept.Difference != 5400 || kept.Earnings != 6000 {
+		t.Errorf("session with unix times was changed: %+v", kept)
+	}
+
+	end, _ := time.ParseInLocation("2006-01-02 15:04", "2024-03-04 11:00", time.Local)
+	repaired := byUUID["text"]
+	duration := end.Unix() - 1709542800
+	if repaired.startUnix != 1709542800 || repaired.endUnix != end.Unix() {
+		t.Errorf("missing end not read from the text: %+v", repaired)
+	}
+	if repaired.Difference != duration || repaired.BilledDifference != duration || repaired.Earnings != earningsFor(4000, time.Duration(duration)*time.Second) {
+		t.Errorf("duration and earnings not recomputed: %+v", repaired)
+	}
+	for _, s := range sessions {
+		if s.TimeZone == "" {
+			t.Errorf("session %q has no zone after the repair", s.Title)
+		}
+	}
+}
@@ -0,0 +1,487 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/google/uuid"
+)
+
+// session lifecycle events sent to webhooks
+const (
+	eventTimerStarted   = "timer.started"
+	eventTimerStopped   = "timer.stopped"
+	eventSessionCreated = "session.created"
+	eventSessionUpdated = "session.updated"
+	eventSessionDeleted = "session.deleted"
+)
+
+// webhookEvents lists all events in the order they are offered in the UI.
+var webhookEvents = []string{eventTimerStarted, eventTimerStopped, eventSessionCreated, eventSessionUpdated, eventSessionDeleted}
+
+// Webhook is an URL that receives session events as JSON POST requests.
+// With a secret, every request carries an HMAC-SHA256 signature of its body
+// in signatureHeader, so the receiver can check it came from us.
+type Webhook struct {
+	URL    string   `json:"url"`
+	Secret string   `json:"secret,omitempty"`
+	Events []string `json:"events,omitempty"` // empty means all events
+}
+
+// wants reports whether the webhook subscribed to event.
+func (h Webhook) wants(event string) bool {
+	return len(h.Events) == 0 || slices.Contains(h.Events, event)
+}
+
+// checkWebhookURL rejects URLs that cannot receive a POST request.
+func checkWebhookURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("invalid webhook URL: %w", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("webhook URL %q must start with http:// or https://", raw)
+	}
+	return nil
+}
+
+const (
+	// signatureHeader carries "sha256=" and the hex HMAC of the request body.
+	signatureHeader = "X-TaskTracker-Signature"
+	// webhookTimeout limits one delivery attempt.
+	webhookTimeout = 10 * time.Second
+	// webhookAttempts is how often a delivery is tried before it is given up.
+	webhookAttempts = 5
+	// webhookBackoff is the wait after the first failed attempt; it doubles
+	// after every further one.
+	webhookBackoff = 2 * time.Second
+	// maxDeliveryLog is how many delivery attempts the log keeps.
+	maxDeliveryLog = 500
+)
+
+// jsonSession is the JSON form of a Session in webhook payloads, hook input
+// and JSON exports. Amounts are decimal strings so receivers need not know
+// about minor units.
+type jsonSession struct {
+	ID           int    `json:"id"`
+	UUID         string `json:"uuid"`
+	Title        string `json:"title"`
+	Description  string `json:"description"`
+	Project      string `json:"project"`
+	Start        string `json:"start"`
+	End          string `json:"end,omitempty"`
+	TimeZone     string `json:"time_zone"`
+	DurationSecs int64  `json:"duration_seconds"`
+	BilledSecs   int64  `json:"billed_seconds"`
+	HourlyRate   string `json:"hourly_rate"`
+	Earnings     string `json:"earnings"`
+	Currency     string `json:"currency"`
+	CreatedBy    string `json:"created_by"`
+	UserName     string `json:"user_name,omitempty"`
+	UserEmail    string `json:"user_email,omitempty"`
+}
+
+// newJSONSession converts s; a running timer has no end yet.
+func newJSONSession(s Session, running bool) jsonSession {
+	ws := jsonSession{
+		ID:           s.ID,
+		UUID:         s.uuid,
+		Title:        s.Title,
+		Description:  s.Description,
+		Project:      s.Project,
+		Start:        s.Start().Format(time.RFC3339),
+		TimeZone:     s.TimeZone,
+		DurationSecs: s.Difference,
+		BilledSecs:   s.BilledDifference,
+		HourlyRate:   s.HourlyRate.Decimal(),
+		Earnings:     s.Earnings.Decimal(),
+		Currency:     s.Currency,
+		CreatedBy:    s.CreatedBy,
+		UserName:     s.UserName,
+		UserEmail:    s.UserEmail,
+	}
+	if !running {
+		ws.End = s.End().Format(time.RFC3339)
+	}
+	return ws
+}
+
+// webhookPayload is the body of a webhook request. ID is the same on every
+// attempt of a delivery, so receivers can drop duplicates.
+type webhookPayload struct {
+	ID      string      `json:"id"`
+	Event   string      `json:"event"`
+	Time    string      `json:"time"`
+	Session jsonSession `json:"session"`
+}
+
+// newWebhookPayload describes event about s with a new delivery ID.
+func newWebhookPayload(event string, s Session) webhookPayload {
+	return webhookPayload{
+		ID:      uuid.NewString(),
+		Event:   event,
+		Time:    time.Now().Format(time.RFC3339),
+		Session: newJSONSession(s, event == eventTimerStarted),
+	}
+}
+
+// signPayload returns the signatureHeader value for body.
+func signPayload(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// createWebhookTable ensures the delivery log table exists.
+func createWebhookTable(db *sql.DB) error {
+	query := `
+    CREATE TABLE IF NOT EXISTS webhook_deliveries (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        delivery_id TEXT NOT NULL,
+        event TEXT NOT NULL,
+        url TEXT NOT NULL,
+        attempt INTEGER NOT NULL,
+        status_code INTEGER NOT NULL DEFAULT 0,
+        error TEXT NOT NULL DEFAULT '',
+        sent_at INTEGER NOT NULL
+    );`
+	_, err := db.Exec(query)
+	return err
+}
+
+// Delivery is one attempt to deliver an event to a webhook.
+type Delivery struct {
+	DeliveryID string
+	Event      string
+	URL        string
+	Attempt    int
+	StatusCode int    // 0 if no response arrived
+	Error      string // empty if the webhook accepted the event
+	SentAt     time.Time
+}
+
+// logDelivery stores d and drops the oldest attempts beyond maxDeliveryLog.
+func logDelivery(db *sql.DB, d Delivery) error {
+	_, err := db.Exec("INSERT INTO webhook_deliveries (delivery_id, event, url, attempt, status_code, error, sent_at) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		d.DeliveryID, d.Event, d.URL, d.Attempt, d.StatusCode, d.Error, d.SentAt.Unix())
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec("DELETE FROM webhook_deliveries WHERE id <= (SELECT MAX(id) FROM webhook_deliveries) - ?", maxDeliveryLog)
+	return err
+}
+
+// recentDeliveries returns the latest delivery attempts, newest first.
+func recentDeliveries(db *sql.DB, limit int) ([]Delivery, error) {
+	rows, err := db.Query("SELECT delivery_id, event, url, attempt, status_code, error, sent_at FROM webhook_deliveries ORDER BY id DESC LIMIT ?", limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []Delivery
+	for rows.Next() {
+		var d Delivery
+		var sentAt int64
+		if err := rows.Scan(&d.DeliveryID, &d.Event, &d.URL, &d.Attempt, &d.StatusCode, &d.Error, &sentAt); err != nil {
+			return nil, err
+		}
+		d.SentAt = time.Unix(sentAt, 0)
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+// httpDoer sends requests; *http.Client implements it.
+type httpDoer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// webhookNotifier delivers events to the webhooks in the settings. Every
+// delivery runs in its own goroutine, so a slow receiver never blocks the UI.
+type webhookNotifier struct {
+	db      *sql.DB
+	cfg     *Config
+	client  httpDoer
+	backoff time.Duration
+	done    chan struct{} // closed by close
+	running sync.WaitGroup
+}
+
+// newWebhookNotifier returns a notifier that logs deliveries to db.
+func newWebhookNotifier(db *sql.DB, cfg *Config) *webhookNotifier {
+	return &webhookNotifier{
+		db:      db,
+		cfg:     cfg,
+		client:  &http.Client{Timeout: webhookTimeout},
+		backoff: webhookBackoff,
+		done:    make(chan struct{}),
+	}
+}
+
+// close is called before db is closed. Attempts under way are finished and
+// logged, retries still waiting are given up, and later events are dropped.
+func (n *webhookNotifier) close() {
+	close(n.done)
+	n.running.Wait()
+}
+
+// notify sends event about s to every webhook that subscribed to it.
+func (n *webhookNotifier) notify(event string, s Session) {
+	select {
+	case <-n.done:
+		return
+	default:
+	}
+	var hooks []Webhook
+	for _, h := range n.cfg.Webhooks {
+		if h.wants(event) {
+			hooks = append(hooks, h)
+		}
+	}
+	if len(hooks) == 0 {
+		return
+	}
+
+	payload := newWebhookPayload(event, s)
+	body, err := json.Marshal(payload)
+	if err != nil {
+		slog.Error("encoding webhook payload", "event", event, "err", err)
+		return
+	}
+	for _, h := range hooks {
+		n.running.Add(1)
+		go func() {
+			defer n.running.Done()
+			n.deliver(h, payload.ID, event, body)
+		}()
+	}
+}
+
+// errPermanent marks responses that a retry would not change.
+var errPermanent = errors.New("rejected by the webhook")
+
+// deliver tries to post body to h until it is accepted, rejected or
+// webhookAttempts have failed, and logs every attempt.
+func (n *webhookNotifier) deliver(h Webhook, id, event string, body []byte) {
+	wait := n.backoff
+	for attempt := 1; ; attempt++ {
+		status, err := n.post(h, id, event, body)
+		d := Delivery{DeliveryID: id, Event: event, URL: h.URL, Attempt: attempt, StatusCode: status, SentAt: time.Now()}
+		if err != nil {
+			d.Error = err.Error()
+		}
+		if logErr := logDelivery(n.db, d); logErr != nil {
+			slog.Warn("logging webhook delivery", "url", h.URL, "err", logErr)
+		}
+		if err == nil {
+			return
+		}
+		if errors.Is(err, errPermanent) || attempt == webhookAttempts {
+			slog.Warn("webhook delivery failed", "url", h.URL, "event", event, "attempts", attempt, "err", err)
+			return
+		}
+		select {
+		case <-time.After(wait):
+		case <-n.done:
+			slog.Warn("webhook delivery given up, the workspace was closed", "url", h.URL, "event", event, "attempts", attempt, "err", err)
+			return
+		}
+		wait *= 2
+	}
+}
+
+// post sends one attempt and returns the response status. Server errors and
+// 429 Too Many Requests are worth a retry; other non-2xx answers are not.
+func (n *webhookNotifier) post(h Webhook, id, event string, body []byte) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, h.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", errPermanent, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "TaskTracker")
+	req.Header.Set("X-TaskTracker-Event", event)
+	req.Header.Set("X-TaskTracker-Delivery", id)
+	if h.Secret != "" {
+		req.Header.Set(signatureHeader, signPayload(h.Secret, body))
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return resp.StatusCode, nil
+	case resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests:
+		return resp.StatusCode, fmt.Errorf("HTTP %s", resp.Status)
+	default:
+		return resp.StatusCode, fmt.Errorf("%w: HTTP %s", errPermanent, resp.Status)
+	}
+}
+
+// notifyingStore passes the sessions created, updated or deleted through it to notify.
+type notifyingStore struct {
+	SessionStore
+	notify func(event string, s Session)
+}
+
+func (st *notifyingStore) Create(s *Session) error {
+	if err := st.SessionStore.Create(s); err != nil {
+		return err
+	}
+	st.notify(eventSessionCreated, *s)
+	return nil
+}
+
+func (st *notifyingStore) Update(s Session) error {
+	if err := st.SessionStore.Update(s); err != nil {
+		return err
+	}
+	st.notify(eventSessionUpdated, s)
+	return nil
+}
+
+// Delete reads the session first, so the event still describes it.
+func (st *notifyingStore) Delete(id int) error {
+	s, err := st.SessionStore.Get(id)
+	if err != nil {
+		return err
+	}
+	if err := st.SessionStore.Delete(id); err != nil {
+		return err
+	}
+	st.notify(eventSessionDeleted, s)
+	return nil
+}
+
+// maxShownDeliveries limits the delivery log on the webhooks tab.
+const maxShownDeliveries = 50
+
+// createWebhooksTab manages the webhooks in the settings and shows the delivery log.
+func createWebhooksTab(db *sql.DB, cfg *Config) fyne.CanvasObject {
+	statusLabel := widget.NewLabel("")
+	parent := fyne.CurrentApp().Driver().AllWindows()[0]
+	hookList := container.NewVBox()
+	logList := container.NewVBox()
+
+	var loadHooks func()
+	loadHooks = func() {
+		hookList.RemoveAll()
+		if len(cfg.Webhooks) == 0 {
+			hookList.Add(widget.NewLabel("No webhooks yet"))
+		}
+		for i, h := range cfg.Webhooks {
+			events := "all events"
+			if len(h.Events) > 0 {
+				events = strings.Join(h.Events, ", ")
+			}
+			if h.Secret != "" {
+				events += ", signed"
+			}
+			removeBtn := widget.NewButton("Remove", func() {
+				dialog.ShowConfirm("Remove webhook", "Stop sending events to "+h.URL+"?", func(ok bool) {
+					if !ok {
+						return
+					}
+					cfg.Webhooks = slices.Delete(cfg.Webhooks, i, i+1)
+					if err := cfg.save(); err != nil {
+						showError("saving settings", err)
+					}
+					loadHooks()
+				}, parent)
+			})
+			hookList.Add(container.NewBorder(nil, nil, nil, removeBtn, widget.NewLabel(h.URL+" ("+events+")")))
+		}
+	}
+
+	loadLog := func() {
+		logList.RemoveAll()
+		deliveries, err := recentDeliveries(db, maxShownDeliveries)
+		if err != nil {
+			showError("reading webhook deliveries", err)
+			return
+		}
+		if len(deliveries) == 0 {
+			logList.Add(widget.NewLabel("Nothing sent yet"))
+		}
+		for _, d := range deliveries {
+			result := "delivered"
+			if d.Error != "" {
+				result = d.Error
+			}
+			logList.Add(widget.NewLabel(fmt.Sprintf("%s  %s  %s  attempt %d: %s", d.SentAt.Format(displayLayout), d.Event, d.URL, d.Attempt, result)))
+		}
+	}
+
+	urlEntry := widget.NewEntry()
+	urlEntry.SetPlaceHolder("https://example.com/hooks/tasktracker")
+	secretEntry := widget.NewPasswordEntry()
+	secretEntry.SetPlaceHolder("Signing secret (optional)")
+	eventChecks := widget.NewCheckGroup(webhookEvents, nil)
+	eventChecks.Horizontal = true
+	eventChecks.SetSelected(webhookEvents)
+
+	addBtn := widget.NewButton("Add webhook", func() {
+		u := strings.TrimSpace(urlEntry.Text)
+		if err := checkWebhookURL(u); err != nil {
+			statusLabel.SetText(err.Error())
+			return
+		}
+		if len(eventChecks.Selected) == 0 {
+			statusLabel.SetText("Choose at least one event")
+			return
+		}
+		h := Webhook{URL: u, Secret: secretEntry.Text}
+		if len(eventChecks.Selected) < len(webhookEvents) {
+			h.Events = slices.Clone(eventChecks.Selected)
+		}
+		cfg.Webhooks = append(cfg.Webhooks, h)
+		if err := cfg.save(); err != nil {
+			showError("saving settings", err)
+			return
+		}
+		statusLabel.SetText("Webhook added")
+		urlEntry.SetText("")
+		secretEntry.SetText("")
+		eventChecks.SetSelected(webhookEvents)
+		loadHooks()
+	})
+
+	loadHooks()
+	loadLog()
+
+	return container.NewVScroll(container.NewVBox(
+		statusLabel,
+		widget.NewLabel("Webhooks"),
+		hookList,
+		urlEntry,
+		secretEntry,
+		eventChecks,
+		addBtn,
+		widget.NewSeparator(),
+		container.NewBorder(nil, nil, nil, widget.NewButton("Refresh", loadLog), widget.NewLabel("Delivery log")),
+		logList,
+	))
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// webhookReceiver is a local stand-in for a webhook. It answers with the
+// statuses in order, repeating the last one, and records every request.
+type webhookReceiver struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []*http.Request
+	bodies   [][]byte
+	received chan struct{}
+}
+
+func newWebhookReceiver(t *testing.T, statuses ...int) (*webhookReceiver, *httptest.Server) {
+	r := &webhookReceiver{statuses: statuses, received: make(chan struct{}, 100)}
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+	return r, srv
+}
+
+func (r *webhookReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	r.mu.Lock()
+	status := r.statuses[min(len(r.requests), len(r.statuses)-1)]
+	r.requests = append(r.requests, req)
+	r.bodies = append(r.bodies, body)
+	r.mu.Unlock()
+	w.WriteHeader(status)
+	r.received <- struct{}{}
+}
+
+func (r *webhookReceiver) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.requests)
+}
+
+// newTestNotifier returns a notifier for hooks that logs to a fresh database
+// and retries almost at once.
+func newTestNotifier(t *testing.T, srv *httptest.Server, hooks ...Webhook) *webhookNotifier {
+	n := newWebhookNotifier(openTestDB(t), &Config{Webhooks: hooks})
+	n.client = srv.Client()
+	n.backoff = time.Millisecond
+	return n
+}
+
+func webhookTestSession() Session {
+	s := newSession("Write docs", "", "Website", testTime(9, 0), testTime(10, 0), "UTC")
+	s.uuid = "00000000-0000-4000-8000-000000000001"
+	return s
+}
+
+func TestWebhookRetries(t *testing.T) {
+	tests := []struct {
+		name      string
+		statuses  []int
+		attempts  int
+		delivered bool
+	}{
+		{"accepted", []int{http.StatusNoContent}, 1, true},
+		{"server errors are retried", []int{500, 503, 200}, 3, true},
+		{"too many requests is retried", []int{http.StatusTooManyRequests, 200}, 2, true},
+		{"client errors are not retried", []int{http.StatusBadRequest}, 1, false},
+		{"gone is not retried", []int{http.StatusGone}, 1, false},
+		{"given up after the last attempt", []int{500}, webhookAttempts, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recv, srv := newWebhookReceiver(t, tt.statuses...)
+			n := newTestNotifier(t, srv, Webhook{URL: srv.URL})
+			n.notify(eventSessionCreated, webhookTestSession())
+			n.running.Wait()
+
+			if got := recv.count(); got != tt.attempts {
+				t.Fatalf("receiver got %d requests, want %d", got, tt.attempts)
+			}
+			id := recv.requests[0].Header.Get("X-TaskTracker-Delivery")
+			for i, req := range recv.requests {
+				if got := req.Header.Get("X-TaskTracker-Delivery"); got != id || id == "" {
+					t.Errorf("attempt %d has delivery id %q, want %q on every attempt", i+1, got, id)
+				}
+			}
+
+			// the log has every attempt, newest first
+			log, err := recentDeliveries(n.db, 100)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(log) != tt.attempts {
+				t.Fatalf("delivery log has %d entries, want %d: %+v", len(log), tt.attempts, log)
+			}
+			for i, d := range log {
+				attempt := tt.attempts - i
+				want := tt.statuses[min(attempt-1, len(tt.statuses)-1)]
+				if d.DeliveryID != id || d.Event != eventSessionCreated || d.URL != srv.URL || d.Attempt != attempt || d.StatusCode != want {
+					t.Errorf("log entry %d = %+v, want attempt %d with status %d", i, d, attempt, want)
+				}
+				if last := i == 0; (d.Error == "") != (last && tt.delivered) {
+					t.Errorf("log entry of attempt %d has error %q", attempt, d.Error)
+				}
+			}
+		})
+	}
+}
+
+func TestWebhookSignature(t *testing.T) {
+	recv, srv := newWebhookReceiver(t, http.StatusOK)
+	n := newTestNotifier(t, srv,
+		Webhook{URL: srv.URL + "/signed", Secret: "s3cret"},
+		Webhook{URL: srv.URL + "/plain"},
+	)
+	n.notify(eventTimerStopped, webhookTestSession())
+	n.running.Wait()
+
+	if recv.count() != 2 {
+		t.Fatalf("receiver got %d requests, want 2", recv.count())
+	}
+	for i, req := range recv.requests {
+		body := recv.bodies[i]
+		signature := req.Header.Get(signatureHeader)
+		switch req.URL.Path {
+		case "/signed":
+			mac := hmac.New(sha256.New, []byte("s3cret"))
+			mac.Write(body)
+			if want := "sha256=" + hex.EncodeToString(mac.Sum(nil)); signature != want {
+				t.Errorf("signature = %q, want %q", signature, want)
+			}
+		case "/plain":
+			if signature != "" {
+				t.Errorf("webhook without secret got signature %q", signature)
+			}
+		}
+		if req.Header.Get("Content-Type") != "application/json" || req.Header.Get("X-TaskTracker-Event") != eventTimerStopped {
+			t.Errorf("unexpected headers %v", req.Header)
+		}
+		var p webhookPayload
+		if err := json.Unmarshal(body, &p); err != nil {
+			t.Fatalf("payload is not JSON: %v", err)
+		}
+		if p.Event != eventTimerStopped || p.Session.UUID != "00000000-0000-4000-8000-000000000001" || p.Session.End == "" {
+			t.Errorf("unexpected payload %+v", p)
+		}
+	}
+}
+
+func TestWebhookEvents(t *testing.T) {
+	recv, srv := newWebhookReceiver(t, http.StatusOK)
+	n := newTestNotifier(t, srv, Webhook{URL: srv.URL, Events: []string{eventSessionDeleted}})
+	n.notify(eventSessionCreated, webhookTestSession())
+	n.notify(eventSessionDeleted, webhookTestSession())
+	n.running.Wait()
+
+	if recv.count() != 1 || recv.requests[0].Header.Get("X-TaskTracker-Event") != eventSessionDeleted {
+		t.Errorf("got %d requests, want only the subscribed %s", recv.count(), eventSessionDeleted)
+	}
+}
+
+func TestWebhookClose(t *testing.T) {
+	recv, srv := newWebhookReceiver(t, http.StatusServiceUnavailable)
+	n := newTestNotifier(t, srv, Webhook{URL: srv.URL})
+	n.backoff = time.Hour
+	n.notify(eventSessionCreated, webhookTestSession())
+	<-recv.received
+
+	closed := make(chan struct{})
+	go func() {
+		n.close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("close waited for the retry")
+	}
+	n.notify(eventSessionDeleted, webhookTestSession())
+
+	if recv.count() != 1 {
+		t.Errorf("receiver got %d requests after close, want 1", recv.count())
+	}
+	log, err := recentDeliveries(n.db, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(log) != 1 {
+		t.Errorf("delivery log has %d entries, want the first attempt only", len(log))
+	}
+}
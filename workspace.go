@@ -0,0 +1,109 @@
+package main
+
+import (
+	"flag"
+	"path/filepath"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// databaseFlags adds the --workspace and --db flags of the window and the commands.
+func databaseFlags(fs *flag.FlagSet) (workspace, db *string) {
+	workspace = fs.String("workspace", "", "workspace to open (default: the last used one)")
+	db = fs.String("db", "", "database file to open instead of a workspace")
+	return workspace, db
+}
+
+// newWorkspaceBar shows the open workspace with a switcher and buttons to add
+// and remove workspaces. current is "" when the database was opened with --db.
+// switchTo is called with the workspace to open after the user confirmed.
+func newWorkspaceBar(cfg *Config, current, path string, switchTo func(name string)) fyne.CanvasObject {
+	parent := fyne.CurrentApp().Driver().AllWindows()[0]
+	pathLabel := widget.NewLabel(path)
+
+	// switching rebuilds the tabs, so unsaved input is lost; ask first
+	var workspaceSelect *widget.Select
+	workspaceSelect = widget.NewSelect(cfg.workspaceNames(), func(name string) {
+		if name == current {
+			return
+		}
+		dialog.ShowConfirm("Switch workspace", "Open workspace "+name+"? A running timer is stopped and saved in this workspace first; other unsaved input is discarded.", func(ok bool) {
+			if !ok {
+				workspaceSelect.SetSelected(current)
+				return
+			}
+			switchTo(name)
+		}, parent)
+	})
+	workspaceSelect.PlaceHolder = "(database file)"
+	if current != "" {
+		workspaceSelect.SetSelected(current)
+	}
+
+	// new workspaces get a file in the config folder unless a path is given
+	newBtn := widget.NewButton("New...", func() {
+		nameEntry := widget.NewEntry()
+		pathEntry := widget.NewEntry()
+		pathEntry.SetPlaceHolder("empty: in the TaskTracker config folder")
+		browseBtn := widget.NewButton("Browse...", func() {
+			fd := dialog.NewFolderOpen(func(dir fyne.ListableURI, err error) {
+				if dir == nil {
+					return
+				}
+				name := nameEntry.Text
+				if name == "" {
+					name = "taskTracker"
+				}
+				pathEntry.SetText(filepath.Join(dir.Path(), name+".db"))
+			}, parent)
+			fd.Show()
+		})
+		items := []*widget.FormItem{
+			widget.NewFormItem("Name", nameEntry),
+			widget.NewFormItem("Database file", container.NewBorder(nil, nil, nil, browseBtn, pathEntry)),
+		}
+		form := dialog.NewForm("New workspace", "Create and open", "Cancel", items, func(ok bool) {
+			if !ok {
+				return
+			}
+			if err := cfg.addWorkspace(Workspace{Name: nameEntry.Text, DBPath: pathEntry.Text}); err != nil {
+				dialog.ShowError(err, parent)
+				return
+			}
+			if err := cfg.save(); err != nil {
+				showError("saving settings", err)
+				return
+			}
+			switchTo(cfg.Workspaces[len(cfg.Workspaces)-1].Name)
+		}, parent)
+		form.Resize(fyne.NewSize(500, 200))
+		form.Show()
+	})
+
+	// removing forgets the workspace and opens the default one; the file stays
+	removeBtn := widget.NewButton("Remove", func() {
+		dialog.ShowConfirm("Remove workspace", "Remove workspace "+current+"? Its database "+path+" is kept.", func(ok bool) {
+			if !ok {
+				return
+			}
+			cfg.deleteWorkspace(current)
+			if err := cfg.save(); err != nil {
+				showError("saving settings", err)
+				return
+			}
+			switchTo(defaultWorkspace)
+		}, parent)
+	})
+	if current == "" || current == defaultWorkspace {
+		removeBtn.Disable()
+	}
+
+	return container.NewBorder(nil, nil,
+		container.NewHBox(widget.NewLabel("Workspace:"), workspaceSelect, newBtn, removeBtn),
+		nil,
+		pathLabel,
+	)
+}
@@ -0,0 +1,713 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Working time: every work day has the weekly target divided by the number
+// of work days as its target. Public holidays and absences (vacation, sick
+// days) have none. Tracked time minus target is the overtime; its running
+// sum over the periods of a range is the balance.
+
+// dayLayout stores days of holidays and absences as text.
+const dayLayout = "2006-01-02"
+
+// absence kinds
+const (
+	absenceVacation = "vacation"
+	absenceSick     = "sick"
+)
+
+var absenceKinds = []string{absenceVacation, absenceSick}
+
+// defaultWorkDays are the work days when the settings name none.
+var defaultWorkDays = []string{"monday", "tuesday", "wednesday", "thursday", "friday"}
+
+// allWeekdays lists the day names offered as work days, in week order.
+var allWeekdays = []string{"monday", "tuesday", "wednesday", "thursday", "friday", "saturday", "sunday"}
+
+// workDays returns the weekdays with a target.
+func (c *Config) workDays() map[time.Weekday]bool {
+	names := c.WorkDays
+	if len(names) == 0 {
+		names = defaultWorkDays
+	}
+	days := map[time.Weekday]bool{}
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		for _, name := range names {
+			if strings.EqualFold(d.String(), name) {
+				days[d] = true
+			}
+		}
+	}
+	return days
+}
+
+// dailyTarget returns the target of one work day.
+func (c *Config) dailyTarget() time.Duration {
+	days := len(c.workDays())
+	if days == 0 {
+		return 0
+	}
+	return time.Duration(c.WeeklyTargetMinutes) * time.Minute / time.Duration(days)
+}
+
+// parseHours reads hours like "40", "38.5" or "38,5" as minutes.
+func parseHours(input string) (int, error) {
+	s := strings.Replace(strings.TrimSpace(input), ",", ".", 1)
+	if s == "" {
+		return 0, nil
+	}
+	h, err := strconv.ParseFloat(s, 64)
+	if err != nil || h < 0 || h > 168 {
+		return 0, fmt.Errorf("invalid number of hours %q", input)
+	}
+	return int(h*60 + 0.5), nil
+}
+
+// createWorkTimeTables ensures the holiday and absence tables exist. Holidays
+// belong to the calendar file they were imported from, so a calendar can be
+// replaced or removed as a whole.
+func createWorkTimeTables(db *sql.DB) error {
+	query := `
+    CREATE TABLE IF NOT EXISTS holidays (
+        day TEXT NOT NULL,
+        name TEXT NOT NULL,
+        calendar TEXT NOT NULL,
+        PRIMARY KEY (day, calendar)
+    );
+    CREATE TABLE IF NOT EXISTS absences (
+        day TEXT PRIMARY KEY,
+        kind TEXT NOT NULL,
+        note TEXT NOT NULL DEFAULT ''
+    );`
+	_, err := db.Exec(query)
+	return err
+}
+
+// holidayYears is how many years ahead yearly holidays without an end are
+// repeated.
+const holidayYears = 10
+
+// Holiday is a day off named in a holiday calendar.
+type Holiday struct {
+	Day  string // dayLayout
+	Name string
+}
+
+// holidayDays returns the days of the all-day events; an event spanning
+// several days makes each of them a holiday. Yearly events on a fixed date
+// are repeated up to the end of the year of until at most. Other events are
+// ignored; other recurrence rules, such as "the fourth Thursday of
+// November", are an error because their days cannot be told.
+func holidayDays(events []icsEvent, until time.Time) ([]Holiday, error) {
+	var holidays []Holiday
+	for _, e := range events {
+		if !e.AllDay {
+			continue
+		}
+		starts, err := e.yearlyOccurrences(time.Date(until.Year(), 12, 31, 0, 0, 0, 0, e.Start.Location()))
+		if err != nil {
+			return nil, err
+		}
+		for _, start := range starts {
+			end := start.AddDate(0, 0, int(e.End.Sub(e.Start).Round(24*time.Hour)/(24*time.Hour)))
+			for d := start; d.Before(end); d = d.AddDate(0, 0, 1) {
+				holidays = append(holidays, Holiday{Day: d.Format(dayLayout), Name: e.Summary})
+			}
+		}
+	}
+	return holidays, nil
+}
+
+// yearlyOccurrences returns the days the all-day event starts on: the first
+// one, those of a yearly rule on the same date up to last, and the RDATEs,
+// without the EXDATEs.
+func (e icsEvent) yearlyOccurrences(last time.Time) ([]time.Time, error) {
+	starts := []time.Time{e.Start}
+	if e.RRule != "" {
+		unsupported := fmt.Errorf("holiday %q repeats with RRULE:%s; only yearly holidays on the same date can be imported, use a calendar that lists the days of the others", e.Summary, e.RRule)
+		interval, count := 1, 0
+		for _, part := range strings.Split(strings.ToUpper(e.RRule), ";") {
+			key, value, _ := strings.Cut(part, "=")
+			var err error
+			switch key {
+			case "FREQ":
+				if value != "YEARLY" {
+					return nil, unsupported
+				}
+			case "INTERVAL":
+				if interval, err = strconv.Atoi(value); err != nil || interval < 1 {
+					return nil, unsupported
+				}
+			case "COUNT":
+				if count, err = strconv.Atoi(value); err != nil || count < 1 {
+					return nil, unsupported
+				}
+			case "UNTIL":
+				until, _, _, err := parseICSTime(value, nil)
+				if err != nil {
+					return nil, unsupported
+				}
+				if until.Before(last) {
+					last = until
+				}
+			case "BYMONTH":
+				if value != strconv.Itoa(int(e.Start.Month())) {
+					return nil, unsupported
+				}
+			case "BYMONTHDAY":
+				if value != strconv.Itoa(e.Start.Day()) {
+					return nil, unsupported
+				}
+			case "WKST":
+			default:
+				return nil, unsupported
+			}
+		}
+		for year := e.Start.Year() + interval; count == 0 || len(starts) < count; year += interval {
+			d := time.Date(year, e.Start.Month(), e.Start.Day(), 0, 0, 0, 0, e.Start.Location())
+			if d.After(last) {
+				break
+			}
+			// February 29 only repeats in leap years
+			if d.Day() == e.Start.Day() {
+				starts = append(starts, d)
+			}
+		}
+	}
+	starts = append(starts, e.RDates...)
+
+	excluded := map[string]bool{}
+	for _, d := range e.ExDates {
+		excluded[d.Format(dayLayout)] = true
+	}
+	var kept []time.Time
+	for _, d := range starts {
+		if !excluded[d.Format(dayLayout)] {
+			kept = append(kept, d)
+		}
+	}
+	return kept, nil
+}
+
+// importHolidays replaces the holidays of calendar. It returns the number of
+// days stored.
+func importHolidays(db *sql.DB, calendar string, holidays []Holiday) (int, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM holidays WHERE calendar = ?", calendar); err != nil {
+		return 0, err
+	}
+	stored := 0
+	for _, h := range holidays {
+		res, err := tx.Exec("INSERT OR IGNORE INTO holidays (day, name, calendar) VALUES (?, ?, ?)", h.Day, h.Name, calendar)
+		if err != nil {
+			return 0, err
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			stored++
+		}
+	}
+	return stored, tx.Commit()
+}
+
+// HolidayCalendar is an imported calendar and how many holidays it has.
+type HolidayCalendar struct {
+	Name string
+	Days int
+}
+
+// holidayCalendars returns the imported calendars by name.
+func holidayCalendars(db *sql.DB) ([]HolidayCalendar, error) {
+	rows, err := db.Query("SELECT calendar, COUNT(*) FROM holidays GROUP BY calendar ORDER BY calendar")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var calendars []HolidayCalendar
+	for rows.Next() {
+		var c HolidayCalendar
+		if err := rows.Scan(&c.Name, &c.Days); err != nil {
+			return nil, err
+		}
+		calendars = append(calendars, c)
+	}
+	return calendars, rows.Err()
+}
+
+// deleteHolidayCalendar removes all holidays of calendar.
+func deleteHolidayCalendar(db *sql.DB, calendar string) error {
+	_, err := db.Exec("DELETE FROM holidays WHERE calendar = ?", calendar)
+	return err
+}
+
+// holidaysBetween returns the holiday names by day in [from, to).
+func holidaysBetween(db *sql.DB, from, to time.Time) (map[string]string, error) {
+	rows, err := db.Query("SELECT day, name FROM holidays WHERE day >= ? AND day < ? ORDER BY day", from.Format(dayLayout), to.Format(dayLayout))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	holidays := map[string]string{}
+	for rows.Next() {
+		var day, name string
+		if err := rows.Scan(&day, &name); err != nil {
+			return nil, err
+		}
+		if _, ok := holidays[day]; !ok {
+			holidays[day] = name
+		}
+	}
+	return holidays, rows.Err()
+}
+
+// Absence is a vacation or sick day.
+type Absence struct {
+	Day  string // dayLayout
+	Kind string // one of absenceKinds
+	Note string
+}
+
+// addAbsence records kind for the work days from first to last, inclusive.
+// Holidays are skipped, so they do not use up vacation; days that already
+// have an absence get the new one. It returns the number of days recorded.
+func addAbsence(db *sql.DB, cfg *Config, kind string, first, last time.Time, note string) (int, error) {
+	if last.Before(first) {
+		return 0, fmt.Errorf("the last day is before the first")
+	}
+	holidays, err := holidaysBetween(db, first, last.AddDate(0, 0, 1))
+	if err != nil {
+		return 0, err
+	}
+	workDays := cfg.workDays()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	added := 0
+	for d := first; !d.After(last); d = d.AddDate(0, 0, 1) {
+		day := d.Format(dayLayout)
+		if _, ok := holidays[day]; ok || !workDays[d.Weekday()] {
+			continue
+		}
+		if _, err := tx.Exec("INSERT OR REPLACE INTO absences (day, kind, note) VALUES (?, ?, ?)", day, kind, note); err != nil {
+			return 0, err
+		}
+		added++
+	}
+	return added, tx.Commit()
+}
+
+// absencesBetween returns the absences in [from, to) by day.
+func absencesBetween(db *sql.DB, from, to time.Time) (map[string]Absence, error) {
+	rows, err := db.Query("SELECT day, kind, note FROM absences WHERE day >= ? AND day < ? ORDER BY day", from.Format(dayLayout), to.Format(dayLayout))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	absences := map[string]Absence{}
+	for rows.Next() {
+		var a Absence
+		if err := rows.Scan(&a.Day, &a.Kind, &a.Note); err != nil {
+			return nil, err
+		}
+		absences[a.Day] = a
+	}
+	return absences, rows.Err()
+}
+
+// deleteAbsence removes the absence on day.
+func deleteAbsence(db *sql.DB, day string) error {
+	_, err := db.Exec("DELETE FROM absences WHERE day = ?", day)
+	return err
+}
+
+// WorkPeriod is the working time of one week or month of a balance.
+type WorkPeriod struct {
+	Label    string
+	From, To time.Time // To is exclusive
+	Target   time.Duration
+	Worked   time.Duration
+	Overtime time.Duration // Worked - Target
+	Balance  time.Duration // running sum of Overtime up to this period
+	Holidays int
+	Vacation int
+	Sick     int
+}
+
+// midnight returns the start of t's day.
+func midnight(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// workBalance splits [from, to) into weeks or months and sums target and
+// tracked time per period. from and to are midnights; periods on the edges
+// are cut to the range.
+func workBalance(sessions []Session, holidays map[string]string, absences map[string]Absence, cfg *Config, period string, from, to time.Time) []WorkPeriod {
+	// tracked time per day, sessions over midnight split between the days
+	worked := map[string]time.Duration{}
+	for _, s := range sessions {
+		start := midnight(time.Unix(s.startUnix, 0).In(from.Location()))
+		end := time.Unix(s.endUnix, 0).In(from.Location())
+		for d := start; d.Before(end); d = d.AddDate(0, 0, 1) {
+			if part := clipSession(s, d, d.AddDate(0, 0, 1)); part.Difference > 0 {
+				worked[d.Format(dayLayout)] += part.Duration()
+			}
+		}
+	}
+
+	workDays := cfg.workDays()
+	target := cfg.dailyTarget()
+	var periods []WorkPeriod
+	var balance time.Duration
+	for d := from; d.Before(to); {
+		pFrom, pTo := periodRange(period, d, cfg.weekStartDay())
+		if pFrom.Before(from) {
+			pFrom = from
+		}
+		if pTo.After(to) {
+			pTo = to
+		}
+		p := WorkPeriod{From: pFrom, To: pTo}
+		if period == periodMonth {
+			p.Label = pFrom.Format("January 2006")
+		} else {
+			p.Label = "Week " + pFrom.Format("2006-01-02")
+		}
+		for ; d.Before(pTo); d = d.AddDate(0, 0, 1) {
+			day := d.Format(dayLayout)
+			p.Worked += worked[day]
+			if !workDays[d.Weekday()] {
+				continue
+			}
+			if _, ok := holidays[day]; ok {
+				p.Holidays++
+				continue
+			}
+			if a, ok := absences[day]; ok {
+				if a.Kind == absenceSick {
+					p.Sick++
+				} else {
+					p.Vacation++
+				}
+				continue
+			}
+			p.Target += target
+		}
+		p.Overtime = p.Worked - p.Target
+		balance += p.Overtime
+		p.Balance = balance
+		periods = append(periods, p)
+	}
+	return periods
+}
+
+// signedHours formats d as hours with a sign, e.g. "+1.50" or "-0.25".
+func signedHours(d time.Duration) string {
+	if d < 0 {
+		return "-" + hours(-d)
+	}
+	return "+" + hours(d)
+}
+
+// writeWorkBalanceCSV writes one row per period.
+func writeWorkBalanceCSV(w io.Writer, periods []WorkPeriod, delimiter rune) error {
+	_, _ = w.Write([]byte{0xEF, 0xBB, 0xBF})
+	writer := csv.NewWriter(w)
+	writer.Comma = delimiter
+
+	header := []string{"Period", "From", "To", "Target Hours", "Worked Hours", "Overtime Hours", "Balance Hours", "Holidays", "Vacation Days", "Sick Days"}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	for _, p := range periods {
+		record := []string{p.Label, p.From.Format(dayLayout), p.To.AddDate(0, 0, -1).Format(dayLayout),
+			hours(p.Target), hours(p.Worked), signedHours(p.Overtime), signedHours(p.Balance),
+			strconv.Itoa(p.Holidays), strconv.Itoa(p.Vacation), strconv.Itoa(p.Sick)}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// parseDay reads a day input like "today", "monday" or "2024-03-01" as its midnight.
+func parseDay(input string, now time.Time) (time.Time, error) {
+	t, err := parseTimeInput(input, now, time.Local)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return midnight(t), nil
+}
+
+// createWorkTimeTab records absences, imports holiday calendars and shows the
+// overtime balance against the weekly target in the settings.
+func createWorkTimeTab(db *sql.DB, store SessionStore, cfg *Config) fyne.CanvasObject {
+	statusLabel := widget.NewLabel("")
+	statusLabel.Wrapping = fyne.TextWrapWord
+	parent := fyne.CurrentApp().Driver().AllWindows()[0]
+
+	// balance over a range, by default the current year up to today
+	periodSelect := widget.NewSelect([]string{periodWeek, periodMonth}, nil)
+	periodSelect.SetSelected(periodMonth)
+	rangeStart := newTimeEntry("First day (empty: start of the year)")
+	rangeEnd := newTimeEntry("Last day (empty: today)")
+	balanceLabel := widget.NewLabel("")
+
+	build := func() ([]WorkPeriod, error) {
+		now := time.Now()
+		from := time.Date(now.Year(), 1, 1, 0, 0, 0, 0, time.Local)
+		to := midnight(now).AddDate(0, 0, 1)
+		var err error
+		if strings.TrimSpace(rangeStart.Text) != "" {
+			if from, err = parseDay(rangeStart.Text, now); err != nil {
+				return nil, fmt.Errorf("invalid first day: %w", err)
+			}
+		}
+		if strings.TrimSpace(rangeEnd.Text) != "" {
+			last, err := parseDay(rangeEnd.Text, now)
+			if err != nil {
+				return nil, fmt.Errorf("invalid last day: %w", err)
+			}
+			to = last.AddDate(0, 0, 1)
+		}
+		if !from.Before(to) {
+			return nil, fmt.Errorf("the first day is after the last")
+		}
+
+		sessions, err := store.List(SessionFilter{From: from, To: to, Mode: rangeOverlapping})
+		if err != nil {
+			return nil, fmt.Errorf("error reading sessions: %w", err)
+		}
+		holidays, err := holidaysBetween(db, from, to)
+		if err != nil {
+			return nil, fmt.Errorf("error reading holidays: %w", err)
+		}
+		absences, err := absencesBetween(db, from, to)
+		if err != nil {
+			return nil, fmt.Errorf("error reading absences: %w", err)
+		}
+		return workBalance(sessions, holidays, absences, cfg, periodSelect.Selected, from, to), nil
+	}
+
+	showBtn := widget.NewButton("Show balance", func() {
+		if cfg.WeeklyTargetMinutes == 0 {
+			statusLabel.SetText("Set the weekly target hours in the settings first")
+			return
+		}
+		periods, err := build()
+		if err != nil {
+			statusLabel.SetText(err.Error())
+			return
+		}
+		var text strings.Builder
+		for _, p := range periods {
+			fmt.Fprintf(&text, "%s: target %s h, worked %s h, %s h, balance %s h", p.Label, hours(p.Target), hours(p.Worked), signedHours(p.Overtime), signedHours(p.Balance))
+			var off []string
+			if p.Holidays > 0 {
+				off = append(off, fmt.Sprintf("%d holidays", p.Holidays))
+			}
+			if p.Vacation > 0 {
+				off = append(off, fmt.Sprintf("%d vacation", p.Vacation))
+			}
+			if p.Sick > 0 {
+				off = append(off, fmt.Sprintf("%d sick", p.Sick))
+			}
+			if len(off) > 0 {
+				text.WriteString(" (" + strings.Join(off, ", ") + ")")
+			}
+			text.WriteString("\n")
+		}
+		balanceLabel.SetText(text.String())
+		statusLabel.SetText("")
+	})
+	csvBtn := widget.NewButton("Export balance to CSV", func() {
+		if cfg.WeeklyTargetMinutes == 0 {
+			statusLabel.SetText("Set the weekly target hours in the settings first")
+			return
+		}
+		periods, err := build()
+		if err != nil {
+			statusLabel.SetText(err.Error())
+			return
+		}
+		filename := "balance_" + time.Now().Format("2006-01-02") + ".csv"
+		showExportDialog(cfg, filename, statusLabel, func(w fyne.URIWriteCloser) (string, error) {
+			if err := writeWorkBalanceCSV(w, periods, cfg.csvDelimiter()); err != nil {
+				return "", err
+			}
+			return "Exported balance to " + w.URI().Name(), nil
+		})
+	})
+
+	// absences of the current year, newest first
+	absenceList := container.NewVBox()
+	var loadAbsences func()
+	loadAbsences = func() {
+		absenceList.RemoveAll()
+		now := time.Now()
+		from := time.Date(now.Year(), 1, 1, 0, 0, 0, 0, time.Local)
+		absences, err := absencesBetween(db, from, from.AddDate(1, 0, 0))
+		if err != nil {
+			showError("reading absences", err)
+			return
+		}
+		counts := map[string]int{}
+		days := make([]string, 0, len(absences))
+		for day, a := range absences {
+			counts[a.Kind]++
+			days = append(days, day)
+		}
+		absenceList.Add(widget.NewLabel(fmt.Sprintf("%d: %d vacation days, %d sick days", now.Year(), counts[absenceVacation], counts[absenceSick])))
+		sort.Sort(sort.Reverse(sort.StringSlice(days)))
+		for _, day := range days {
+			a := absences[day]
+			label := a.Day + "  " + a.Kind
+			if a.Note != "" {
+				label += " - " + a.Note
+			}
+			removeBtn := widget.NewButton("Remove", func() {
+				if err := deleteAbsence(db, a.Day); err != nil {
+					showError("removing absence", err)
+					return
+				}
+				loadAbsences()
+			})
+			absenceList.Add(container.NewBorder(nil, nil, nil, removeBtn, widget.NewLabel(label)))
+		}
+	}
+
+	kindSelect := widget.NewSelect(absenceKinds, nil)
+	kindSelect.SetSelected(absenceVacation)
+	firstDay := newTimeEntry("First day, e.g. 2024-08-05")
+	lastDay := newTimeEntry("Last day (empty: only the first)")
+	noteEntry := widget.NewEntry()
+	noteEntry.SetPlaceHolder("Note (optional)")
+	addBtn := widget.NewButton("Add absence", func() {
+		now := time.Now()
+		first, err := parseDay(firstDay.Text, now)
+		if err != nil {
+			statusLabel.SetText("Invalid first day: " + err.Error())
+			return
+		}
+		last := first
+		if strings.TrimSpace(lastDay.Text) != "" {
+			if last, err = parseDay(lastDay.Text, now); err != nil {
+				statusLabel.SetText("Invalid last day: " + err.Error())
+				return
+			}
+		}
+		if last.Before(first) {
+			statusLabel.SetText("The last day is before the first")
+			return
+		}
+		n, err := addAbsence(db, cfg, kindSelect.Selected, first, last, strings.TrimSpace(noteEntry.Text))
+		if err != nil {
+			showError("adding absence", err)
+			return
+		}
+		statusLabel.SetText(fmt.Sprintf("Added %d %s days (weekends and holidays are skipped)", n, kindSelect.Selected))
+		firstDay.SetText("")
+		lastDay.SetText("")
+		noteEntry.SetText("")
+		loadAbsences()
+	})
+
+	// holiday calendars
+	calendarList := container.NewVBox()
+	var loadCalendars func()
+	loadCalendars = func() {
+		calendarList.RemoveAll()
+		calendars, err := holidayCalendars(db)
+		if err != nil {
+			showError("reading holidays", err)
+			return
+		}
+		for _, c := range calendars {
+			removeBtn := widget.NewButton("Remove", func() {
+				if err := deleteHolidayCalendar(db, c.Name); err != nil {
+					showError("removing holidays", err)
+					return
+				}
+				loadCalendars()
+			})
+			calendarList.Add(container.NewBorder(nil, nil, nil, removeBtn, widget.NewLabel(fmt.Sprintf("%s: %d holidays", c.Name, c.Days))))
+		}
+	}
+	importBtn := widget.NewButton("Import public holidays (.ics)...", func() {
+		fd := dialog.NewFileOpen(func(r fyne.URIReadCloser, err error) {
+			if r == nil {
+				return
+			}
+			defer r.Close()
+			events, err := parseICS(r)
+			if err != nil {
+				statusLabel.SetText("Error reading " + r.URI().Name() + ": " + err.Error())
+				return
+			}
+			holidays, err := holidayDays(events, time.Now().AddDate(holidayYears, 0, 0))
+			if err != nil {
+				statusLabel.SetText("Cannot import " + r.URI().Name() + ": " + err.Error())
+				return
+			}
+			n, err := importHolidays(db, r.URI().Name(), holidays)
+			if err != nil {
+				showError("importing holidays", err)
+				return
+			}
+			statusLabel.SetText(fmt.Sprintf("Imported %d holidays from %s", n, r.URI().Name()))
+			loadCalendars()
+		}, parent)
+		fd.SetFilter(storage.NewExtensionFileFilter([]string{".ics"}))
+		fd.Show()
+	})
+
+	loadAbsences()
+	loadCalendars()
+
+	return container.NewVScroll(container.NewVBox(
+		statusLabel,
+		widget.NewLabel("Overtime balance"),
+		container.NewHBox(widget.NewLabel("Per:"), periodSelect),
+		rangeStart,
+		rangeEnd,
+		container.NewHBox(showBtn, csvBtn),
+		balanceLabel,
+		widget.NewSeparator(),
+		widget.NewLabel("Vacation and sick days"),
+		container.NewHBox(widget.NewLabel("Kind:"), kindSelect),
+		firstDay,
+		lastDay,
+		noteEntry,
+		addBtn,
+		absenceList,
+		widget.NewSeparator(),
+		widget.NewLabel("Public holidays"),
+		importBtn,
+		calendarList,
+	))
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// day returns midnight of a day in 2024 in UTC, plus h hours.
+func day(month time.Month, d, h int) time.Time {
+	return time.Date(2024, month, d, h, 0, 0, 0, time.UTC)
+}
+
+func TestDailyTarget(t *testing.T) {
+	tests := []struct {
+		name     string
+		minutes  int
+		workDays []string
+		want     time.Duration
+	}{
+		{"default work days", 40 * 60, nil, 8 * time.Hour},
+		{"four days", 40 * 60, []string{"monday", "tuesday", "wednesday", "thursday"}, 10 * time.Hour},
+		{"fractional hours", 2310, nil, 7*time.Hour + 42*time.Minute},
+		{"names in any case", 30 * 60, []string{"Monday", "WEDNESDAY", "friday"}, 10 * time.Hour},
+		{"no target", 0, nil, 0},
+		{"no valid work day", 40 * 60, []string{"funday"}, 0},
+	}
+	for _, tt := range tests {
+		cfg := &Config{WeeklyTargetMinutes: tt.minutes, WorkDays: tt.workDays}
+		if got := cfg.dailyTarget(); got != tt.want {
+			t.Errorf("%s: dailyTarget = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAddAbsence(t *testing.T) {
+	tests := []struct {
+		name        string
+		workDays    []string
+		first, last time.Time
+		want        []string
+		wantErr     bool
+	}{
+		// Wednesday 2024-03-06 is a holiday
+		{"week without holiday and weekend", nil, day(3, 4, 0), day(3, 10, 0), []string{"2024-03-04", "2024-03-05", "2024-03-07", "2024-03-08"}, false},
+		{"single day", nil, day(3, 5, 0), day(3, 5, 0), []string{"2024-03-05"}, false},
+		{"only the holiday", nil, day(3, 6, 0), day(3, 6, 0), nil, false},
+		{"weekend", nil, day(3, 9, 0), day(3, 10, 0), nil, false},
+		{"other work days", []string{"saturday", "sunday"}, day(3, 4, 0), day(3, 10, 0), []string{"2024-03-09", "2024-03-10"}, false},
+		{"last before first", nil, day(3, 5, 0), day(3, 4, 0), nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := openTestDB(t)
+			if _, err := importHolidays(db, "holidays.ics", []Holiday{{Day: "2024-03-06", Name: "Holiday"}}); err != nil {
+				t.Fatal(err)
+			}
+			cfg := &Config{WorkDays: tt.workDays}
+			n, err := addAbsence(db, cfg, absenceVacation, tt.first, tt.last, "trip")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("addAbsence: err = %v, want error %v", err, tt.wantErr)
+			}
+			if n != len(tt.want) {
+				t.Errorf("addAbsence = %d days, want %d", n, len(tt.want))
+			}
+			absences, err := absencesBetween(db, day(3, 1, 0), day(4, 1, 0))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(absences) != len(tt.want) {
+				t.Errorf("stored absences %v, want %v", absences, tt.want)
+			}
+			for _, d := range tt.want {
+				if a := absences[d]; a.Kind != absenceVacation || a.Note != "trip" {
+					t.Errorf("absence on %s = %+v", d, a)
+				}
+			}
+		})
+	}
+
+	// a sick day replaces the vacation on the same day
+	db := openTestDB(t)
+	if _, err := addAbsence(db, &Config{}, absenceVacation, day(3, 4, 0), day(3, 5, 0), ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := addAbsence(db, &Config{}, absenceSick, day(3, 5, 0), day(3, 5, 0), "flu"); err != nil {
+		t.Fatal(err)
+	}
+	absences, err := absencesBetween(db, day(3, 1, 0), day(4, 1, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if absences["2024-03-04"].Kind != absenceVacation || absences["2024-03-05"].Kind != absenceSick || len(absences) != 2 {
+		t.Errorf("absences after the sick day = %v", absences)
+	}
+}
+
+func TestWorkBalance(t *testing.T) {
+	session := func(start, end time.Time) Session {
+		return newSession("Work", "", "", start, end, "UTC")
+	}
+	week := []Session{
+		session(day(3, 4, 9), day(3, 4, 17)),   // Monday, 8h
+		session(day(3, 5, 9), day(3, 5, 19)),   // Tuesday, 10h
+		session(day(3, 9, 22), day(3, 10, 2)),  // Saturday night, 4h
+		session(day(3, 10, 22), day(3, 11, 2)), // Sunday night, 2h in the week
+	}
+	tests := []struct {
+		name     string
+		period   string
+		from, to time.Time
+		sessions []Session
+		holidays map[string]string
+		absences map[string]Absence
+		want     []WorkPeriod
+	}{
+		{
+			name: "week", period: periodWeek, from: day(3, 4, 0), to: day(3, 11, 0), sessions: week,
+			want: []WorkPeriod{{Label: "Week 2024-03-04", From: day(3, 4, 0), To: day(3, 11, 0), Target: 40 * time.Hour, Worked: 24 * time.Hour, Overtime: -16 * time.Hour, Balance: -16 * time.Hour}},
+		},
+		{
+			name: "holiday, vacation and sick day", period: periodWeek, from: day(3, 4, 0), to: day(3, 11, 0), sessions: week,
+			holidays: map[string]string{"2024-03-06": "Holiday", "2024-03-09": "Saturday holiday"},
+			absences: map[string]Absence{"2024-03-07": {Day: "2024-03-07", Kind: absenceVacation}, "2024-03-08": {Day: "2024-03-08", Kind: absenceSick}},
+			want:     []WorkPeriod{{Label: "Week 2024-03-04", From: day(3, 4, 0), To: day(3, 11, 0), Target: 16 * time.Hour, Worked: 24 * time.Hour, Overtime: 8 * time.Hour, Balance: 8 * time.Hour, Holidays: 1, Vacation: 1, Sick: 1}},
+		},
+		{
+			name: "weeks cut to the range", period: periodWeek, from: day(2, 29, 0), to: day(3, 6, 0), sessions: week,
+			want: []WorkPeriod{
+				{Label: "Week 2024-02-29", From: day(2, 29, 0), To: day(3, 4, 0), Target: 16 * time.Hour, Overtime: -16 * time.Hour, Balance: -16 * time.Hour},
+				{Label: "Week 2024-03-04", From: day(3, 4, 0), To: day(3, 6, 0), Target: 16 * time.Hour, Worked: 18 * time.Hour, Overtime: 2 * time.Hour, Balance: -14 * time.Hour},
+			},
+		},
+		{
+			name: "months", period: periodMonth, from: day(2, 26, 0), to: day(3, 11, 0), sessions: week,
+			want: []WorkPeriod{
+				{Label: "February 2024", From: day(2, 26, 0), To: day(3, 1, 0), Target: 32 * time.Hour, Overtime: -32 * time.Hour, Balance: -32 * time.Hour},
+				{Label: "March 2024", From: day(3, 1, 0), To: day(3, 11, 0), Target: 48 * time.Hour, Worked: 24 * time.Hour, Overtime: -24 * time.Hour, Balance: -56 * time.Hour},
+			},
+		},
+	}
+	cfg := &Config{WeeklyTargetMinutes: 40 * 60, WeekStart: "monday"}
+	for _, tt := range tests {
+		got := workBalance(tt.sessions, tt.holidays, tt.absences, cfg, tt.period, tt.from, tt.to)
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: %d periods, want %d: %+v", tt.name, len(got), len(tt.want), got)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%s: period %d = %+v\nwant %+v", tt.name, i, got[i], tt.want[i])
+			}
+		}
+	}
+}
+
+// calendar returns an iCalendar file with one event of the lines.
+func calendar(lines ...string) string {
+	return "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nSUMMARY:Holiday\r\n" + strings.Join(lines, "\r\n") + "\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+}
+
+func TestHolidayDays(t *testing.T) {
+	until := time.Date(2028, 1, 1, 0, 0, 0, 0, time.Local)
+	tests := []struct {
+		name string
+		ics  string
+		want string
+	}{
+		{"single day", calendar("DTSTART;VALUE=DATE:20240101"), "2024-01-01"},
+		{"several days", calendar("DTSTART;VALUE=DATE:20241224", "DTEND;VALUE=DATE:20241227"), "2024-12-24 2024-12-25 2024-12-26"},
+		{"timed event", calendar("DTSTART:20240101T090000Z", "DTEND:20240101T100000Z"), ""},
+		{"yearly without end", calendar("DTSTART;VALUE=DATE:20240501", "RRULE:FREQ=YEARLY"), "2024-05-01 2025-05-01 2026-05-01 2027-05-01 2028-05-01"},
+		{"yearly by month day", calendar("DTSTART;VALUE=DATE:20240501", "RRULE:FREQ=YEARLY;BYMONTH=5;BYMONTHDAY=1"), "2024-05-01 2025-05-01 2026-05-01 2027-05-01 2028-05-01"},
+		{"count", calendar("DTSTART;VALUE=DATE:20240501", "RRULE:FREQ=YEARLY;COUNT=2"), "2024-05-01 2025-05-01"},
+		{"until", calendar("DTSTART;VALUE=DATE:20231225", "RRULE:FREQ=YEARLY;UNTIL=20251231"), "2023-12-25 2024-12-25 2025-12-25"},
+		{"interval", calendar("DTSTART;VALUE=DATE:20240501", "RRULE:FREQ=YEARLY;INTERVAL=2"), "2024-05-01 2026-05-01 2028-05-01"},
+		{"leap day", calendar("DTSTART;VALUE=DATE:20240229", "RRULE:FREQ=YEARLY"), "2024-02-29 2028-02-29"},
+		{"several days every year", calendar("DTSTART;VALUE=DATE:20261224", "DTEND;VALUE=DATE:20261226", "RRULE:FREQ=YEARLY"), "2026-12-24 2026-12-25 2027-12-24 2027-12-25 2028-12-24 2028-12-25"},
+		{"dates added and left out", calendar("DTSTART;VALUE=DATE:20250501", "RRULE:FREQ=YEARLY;COUNT=3", "EXDATE;VALUE=DATE:20260501", "RDATE;VALUE=DATE:20250609,20260525"), "2025-05-01 2027-05-01 2025-06-09 2026-05-25"},
+	}
+	for _, tt := range tests {
+		events, err := parseICS(strings.NewReader(tt.ics))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		holidays, err := holidayDays(events, until)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		var days []string
+		for _, h := range holidays {
+			days = append(days, h.Day)
+			if h.Name != "Holiday" {
+				t.Errorf("%s: holiday %s is named %q", tt.name, h.Day, h.Name)
+			}
+		}
+		if got := strings.Join(days, " "); got != tt.want {
+			t.Errorf("%s: days = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	for _, rule := range []string{
+		"FREQ=YEARLY;BYMONTH=11;BYDAY=4TH",
+		"FREQ=YEARLY;BYMONTHDAY=2",
+		"FREQ=YEARLY;BYMONTH=6",
+		"FREQ=MONTHLY",
+		"FREQ=YEARLY;COUNT=0",
+		"FREQ=YEARLY;INTERVAL=x",
+	} {
+		events, err := parseICS(strings.NewReader(calendar("DTSTART;VALUE=DATE:20240501", "RRULE:"+rule)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := holidayDays(events, until); err == nil || !strings.Contains(err.Error(), "RRULE:"+rule) {
+			t.Errorf("RRULE:%s: err = %v, want it rejected by name", rule, err)
+		}
+	}
+}
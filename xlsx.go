@@ -0,0 +1,329 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// number formats used in exported workbooks
+const (
+	xlsxDateFormat     = "yyyy-mm-dd"
+	xlsxDateTimeFormat = "yyyy-mm-dd hh:mm"
+	xlsxDurationFormat = "[h]:mm:ss"
+	xlsxMoneyFormat    = "#,##0.00"
+)
+
+// xlsxStyles holds the style ids of a workbook.
+type xlsxStyles struct {
+	header, date, dateTime, duration, money int
+	totalLabel, totalDuration, totalMoney   int
+}
+
+// newXLSXStyles registers the export styles in f.
+func newXLSXStyles(f *excelize.File) (xlsxStyles, error) {
+	var s xlsxStyles
+	headerFill := excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"#DDEBF7"}}
+	totalBorder := []excelize.Border{{Type: "top", Color: "#000000", Style: 6}}
+	styles := []struct {
+		id    *int
+		style *excelize.Style
+	}{
+		{&s.header, &excelize.Style{Font: &excelize.Font{Bold: true}, Fill: headerFill, Border: []excelize.Border{{Type: "bottom", Color: "#000000", Style: 1}}}},
+		{&s.date, &excelize.Style{CustomNumFmt: strPtr(xlsxDateFormat)}},
+		{&s.dateTime, &excelize.Style{CustomNumFmt: strPtr(xlsxDateTimeFormat)}},
+		{&s.duration, &excelize.Style{CustomNumFmt: strPtr(xlsxDurationFormat)}},
+		{&s.money, &excelize.Style{CustomNumFmt: strPtr(xlsxMoneyFormat)}},
+		{&s.totalLabel, &excelize.Style{Font: &excelize.Font{Bold: true}, Border: totalBorder}},
+		{&s.totalDuration, &excelize.Style{Font: &excelize.Font{Bold: true}, Border: totalBorder, CustomNumFmt: strPtr(xlsxDurationFormat)}},
+		{&s.totalMoney, &excelize.Style{Font: &excelize.Font{Bold: true}, Border: totalBorder, CustomNumFmt: strPtr(xlsxMoneyFormat)}},
+	}
+	for _, st := range styles {
+		id, err := f.NewStyle(st.style)
+		if err != nil {
+			return s, err
+		}
+		*st.id = id
+	}
+	return s, nil
+}
+
+// strPtr returns a pointer to s, for excelize style options.
+func strPtr(s string) *string {
+	return &s
+}
+
+// cellName returns the A1 name of a 1-based column and row.
+func cellName(col, row int) string {
+	name, _ := excelize.CoordinatesToCellName(col, row)
+	return name
+}
+
+// xlsxDuration converts a duration to the fraction of a day Excel uses for times.
+func xlsxDuration(d time.Duration) float64 {
+	return d.Seconds() / 86400
+}
+
+// SessionSummary aggregates sessions sharing a day or title.
+type SessionSummary struct {
+	Key      string
+	Date     time.Time // set for summaries by day
+	Count    int
+	Duration time.Duration
+	Billed   time.Duration
+	Earnings map[string]Money // by currency
+}
+
+// summarize groups sessions by key, sorted by key.
+func summarize(sessions []Session, key func(Session) string) []*SessionSummary {
+	byKey := map[string]*SessionSummary{}
+	for _, s := range sessions {
+		k := key(s)
+		sum := byKey[k]
+		if sum == nil {
+			sum = &SessionSummary{Key: k, Earnings: map[string]Money{}}
+			byKey[k] = sum
+		}
+		sum.Count++
+		sum.Duration += time.Duration(s.Difference) * time.Second
+		sum.Billed += time.Duration(s.BilledDifference) * time.Second
+		sum.Earnings[s.Currency] += s.Earnings
+	}
+	summaries := make([]*SessionSummary, 0, len(byKey))
+	for _, sum := range byKey {
+		summaries = append(summaries, sum)
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].Key < summaries[j].Key })
+	return summaries
+}
+
+// sessionCurrencies returns the sorted currencies used by sessions.
+func sessionCurrencies(sessions []Session) []string {
+	seen := map[string]bool{}
+	var codes []string
+	for _, s := range sessions {
+		if !seen[s.Currency] {
+			seen[s.Currency] = true
+			codes = append(codes, s.Currency)
+		}
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// writeSessionsXLSX writes sessions as a workbook with a "Sessions" sheet of
+// typed cells (dates, durations, amounts) in the given columns, ending in a
+// totals row, plus "By day" and "By title" summary sheets.
+func writeSessionsXLSX(w io.Writer, sessions []Session, cols []exportColumn) error {
+	f := excelize.NewFile()
+	defer f.Close()
+	styles, err := newXLSXStyles(f)
+	if err != nil {
+		return err
+	}
+	currencies := sessionCurrencies(sessions)
+
+	if err := writeSessionsSheet(f, styles, sessions, cols, currencies); err != nil {
+		return err
+	}
+
+	byDay := summarize(sessions, func(s Session) string {
+		return time.Unix(s.startUnix, 0).In(zoneLocation(s.TimeZone)).Format("2006-01-02")
+	})
+	for _, sum := range byDay {
+		sum.Date, _ = time.Parse("2006-01-02", sum.Key)
+	}
+	if err := writeSummarySheet(f, styles, "By day", "Date", byDay, currencies); err != nil {
+		return err
+	}
+
+	byTitle := summarize(sessions, func(s Session) string { return s.Title })
+	if err := writeSummarySheet(f, styles, "By title", "Title", byTitle, currencies); err != nil {
+		return err
+	}
+
+	_, err = f.WriteTo(w)
+	return err
+}
+
+// writeSessionsSheet fills the first sheet with one row per session and a
+// totals row summing durations and earnings (one row per currency when the
+// currency column is exported and currencies are mixed).
+func writeSessionsSheet(f *excelize.File, styles xlsxStyles, sessions []Session, cols []exportColumn, currencies []string) error {
+	sheet := "Sessions"
+	if err := f.SetSheetName("Sheet1", sheet); err != nil {
+		return err
+	}
+	headers := make([]string, len(cols))
+	currencyCol := 0
+	for i, col := range cols {
+		headers[i] = col.Header
+		if col.Key == "currency" {
+			currencyCol = i + 1
+		}
+	}
+	if err := writeXLSXHeader(f, styles, sheet, headers); err != nil {
+		return err
+	}
+
+	for i, s := range sessions {
+		row := make([]any, len(cols))
+		for j, col := range cols {
+			switch v := col.value(s).(type) {
+			case time.Duration:
+				row[j] = xlsxDuration(v)
+			case Money:
+				row[j] = v.Float()
+			default:
+				row[j] = v
+			}
+		}
+		f.SetSheetRow(sheet, cellName(1, i+2), &row)
+	}
+	last := len(sessions) + 1
+	for i, col := range cols {
+		if len(sessions) == 0 {
+			break
+		}
+		switch col.kind {
+		case kindTime:
+			f.SetCellStyle(sheet, cellName(i+1, 2), cellName(i+1, last), styles.dateTime)
+		case kindDuration:
+			f.SetCellStyle(sheet, cellName(i+1, 2), cellName(i+1, last), styles.duration)
+		case kindMoney:
+			f.SetCellStyle(sheet, cellName(i+1, 2), cellName(i+1, last), styles.money)
+		}
+	}
+
+	// totals: durations sum up directly, earnings per currency
+	totalRows := []string{""}
+	if len(currencies) > 1 && currencyCol > 0 {
+		totalRows = currencies
+	}
+	if len(sessions) == 0 {
+		totalRows = nil
+	}
+	row := last + 1
+	for n, code := range totalRows {
+		if !cols[0].total {
+			label := "Total"
+			if code != "" {
+				label += " " + code
+			}
+			f.SetCellValue(sheet, "A"+strconv.Itoa(row), label)
+			f.SetCellStyle(sheet, "A"+strconv.Itoa(row), "A"+strconv.Itoa(row), styles.totalLabel)
+		}
+		for i, col := range cols {
+			colName, _ := excelize.ColumnNumberToName(i + 1)
+			cell := cellName(i+1, row)
+			switch {
+			case col.total && col.kind == kindDuration && n == 0:
+				f.SetCellFormula(sheet, cell, fmt.Sprintf("SUM(%s2:%s%d)", colName, colName, last))
+				f.SetCellStyle(sheet, cell, cell, styles.totalDuration)
+			case col.total && col.kind == kindMoney && code != "":
+				currencyName, _ := excelize.ColumnNumberToName(currencyCol)
+				f.SetCellFormula(sheet, cell, fmt.Sprintf("SUMIF(%s2:%s%d,\"%s\",%s2:%s%d)", currencyName, currencyName, last, code, colName, colName, last))
+				f.SetCellStyle(sheet, cell, cell, styles.totalMoney)
+			case col.total && col.kind == kindMoney && len(currencies) <= 1:
+				f.SetCellFormula(sheet, cell, fmt.Sprintf("SUM(%s2:%s%d)", colName, colName, last))
+				f.SetCellStyle(sheet, cell, cell, styles.totalMoney)
+			case i+1 == currencyCol && code != "":
+				f.SetCellValue(sheet, cell, code)
+			case i+1 == currencyCol && len(currencies) == 1:
+				f.SetCellValue(sheet, cell, currencies[0])
+			}
+		}
+		row++
+	}
+
+	for i, col := range cols {
+		width := 14.0
+		switch col.kind {
+		case kindText:
+			width = 24
+		case kindTime:
+			width = 17
+		}
+		colName, _ := excelize.ColumnNumberToName(i + 1)
+		f.SetColWidth(sheet, colName, colName, width)
+	}
+	return finishXLSXTable(f, sheet, len(headers), last)
+}
+
+// writeSummarySheet adds a sheet with one row per summary and a SUM totals row.
+func writeSummarySheet(f *excelize.File, styles xlsxStyles, sheet, keyHeader string, summaries []*SessionSummary, currencies []string) error {
+	if _, err := f.NewSheet(sheet); err != nil {
+		return err
+	}
+	headers := []string{keyHeader, "Sessions", "Duration", "Billed Duration"}
+	for _, code := range currencies {
+		headers = append(headers, "Earnings "+code)
+	}
+	if err := writeXLSXHeader(f, styles, sheet, headers); err != nil {
+		return err
+	}
+
+	for i, sum := range summaries {
+		row := i + 2
+		var key any = sum.Key
+		if !sum.Date.IsZero() {
+			key = sum.Date
+		}
+		values := []any{key, sum.Count, xlsxDuration(sum.Duration), xlsxDuration(sum.Billed)}
+		for _, code := range currencies {
+			values = append(values, sum.Earnings[code].Float())
+		}
+		f.SetSheetRow(sheet, cellName(1, row), &values)
+	}
+	last := len(summaries) + 1
+	if len(summaries) > 0 {
+		if !summaries[0].Date.IsZero() {
+			f.SetCellStyle(sheet, "A2", cellName(1, last), styles.date)
+		}
+		f.SetCellStyle(sheet, "C2", cellName(4, last), styles.duration)
+		if len(currencies) > 0 {
+			f.SetCellStyle(sheet, "E2", cellName(len(headers), last), styles.money)
+		}
+	}
+
+	total := last + 1
+	f.SetCellValue(sheet, cellName(1, total), "Total")
+	for col := 2; col <= len(headers); col++ {
+		colName, _ := excelize.ColumnNumberToName(col)
+		f.SetCellFormula(sheet, cellName(col, total), fmt.Sprintf("SUM(%s2:%s%d)", colName, colName, last))
+	}
+	f.SetCellStyle(sheet, cellName(1, total), cellName(2, total), styles.totalLabel)
+	f.SetCellStyle(sheet, cellName(3, total), cellName(4, total), styles.totalDuration)
+	if len(currencies) > 0 {
+		f.SetCellStyle(sheet, cellName(5, total), cellName(len(headers), total), styles.totalMoney)
+	}
+
+	lastCol, _ := excelize.ColumnNumberToName(len(headers))
+	f.SetColWidth(sheet, "A", "A", 24)
+	f.SetColWidth(sheet, "B", lastCol, 15)
+	return finishXLSXTable(f, sheet, len(headers), last)
+}
+
+// writeXLSXHeader writes a styled header row.
+func writeXLSXHeader(f *excelize.File, styles xlsxStyles, sheet string, headers []string) error {
+	row := make([]any, len(headers))
+	for i, h := range headers {
+		row[i] = h
+	}
+	if err := f.SetSheetRow(sheet, "A1", &row); err != nil {
+		return err
+	}
+	return f.SetCellStyle(sheet, "A1", cellName(len(headers), 1), styles.header)
+}
+
+// finishXLSXTable freezes the header row and adds an auto-filter over the data rows.
+func finishXLSXTable(f *excelize.File, sheet string, columns, lastRow int) error {
+	if err := f.SetPanes(sheet, &excelize.Panes{Freeze: true, YSplit: 1, TopLeftCell: "A2", ActivePane: "bottomLeft"}); err != nil {
+		return err
+	}
+	return f.AutoFilter(sheet, "A1:"+cellName(columns, lastRow), nil)
+}